@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Avatars bigger than this are refused rather than cached.
+const maxAvatarBytes = 1 << 20
+
+type avatarEntry struct {
+	contentType string
+	image       []byte
+	fetchedAt   time.Time
+}
+
+// avatarCache holds proxied avatars keyed by a hash of the login, evicting
+// the oldest entry once it holds AVATAR_CACHE_SIZE avatars.
+type avatarCache struct {
+	mu      sync.Mutex
+	entries map[string]avatarEntry
+	order   []string
+}
+
+var avatars = avatarCache{entries: map[string]avatarEntry{}}
+
+func (c *avatarCache) get(key string, ttl time.Duration) (avatarEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[key]
+	if !found || now().Sub(entry.fetchedAt) > ttl {
+		return avatarEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *avatarCache) put(key string, entry avatarEntry, maxEntries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.entries[key]; !found {
+		for len(c.order) >= maxEntries {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = entry
+}
+
+// avatarHandler serves /avatar?login=octocat from GitHub's avatar CDN via
+// the server, so the browser never contacts GitHub and its IP stays private.
+func avatarHandler(w http.ResponseWriter, r *http.Request) {
+	login := r.URL.Query().Get("login")
+	if !isGithubLogin(login) {
+		writeJSONError(w, http.StatusBadRequest, "Invalid login", "")
+		return
+	}
+
+	hash := sha256.Sum256([]byte(strings.ToLower(login)))
+	key := hex.EncodeToString(hash[:])
+
+	entry, cached := avatars.get(key, getAvatarCacheTTL())
+	if !cached {
+		var err error
+		entry, err = getGithubAvatar(login)
+		if err != nil {
+			log.Println("[ERROR] Avatar fetch failed:", err)
+			writeJSONError(w, http.StatusBadGateway, "Avatar unavailable", "")
+			return
+		}
+		avatars.put(key, entry, getAvatarCacheSize())
+	}
+
+	w.Header().Set("Content-Type", entry.contentType)
+	w.Write(entry.image)
+}
+
+func getGithubAvatar(login string) (avatarEntry, error) {
+	req, reqerr := http.NewRequest("GET", "https://avatars.githubusercontent.com/"+login, nil)
+	if reqerr != nil {
+		return avatarEntry{}, reqerr
+	}
+
+	image, header, err := doGithubRequestLimit(req, maxAvatarBytes)
+	if errors.Is(err, errResponseTooLarge) {
+		return avatarEntry{}, errors.New("avatar is larger than 1MB")
+	}
+	if err != nil {
+		return avatarEntry{}, err
+	}
+
+	contentType := header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return avatarEntry{}, errors.New("avatar response is not an image: " + contentType)
+	}
+
+	return avatarEntry{contentType: contentType, image: image, fetchedAt: now()}, nil
+}
+
+// isGithubLogin accepts GitHub's username rules: up to 39 letters, digits or
+// single hyphens, not starting or ending with a hyphen.
+func isGithubLogin(login string) bool {
+	if login == "" || len(login) > 39 || strings.HasPrefix(login, "-") || strings.HasSuffix(login, "-") || strings.Contains(login, "--") {
+		return false
+	}
+	for _, c := range login {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func resetAvatarCache(t *testing.T) {
+	avatars = avatarCache{entries: map[string]avatarEntry{}}
+	t.Cleanup(func() { avatars = avatarCache{entries: map[string]avatarEntry{}} })
+}
+
+func TestAvatarProxiedAndCached(t *testing.T) {
+	resetAvatarCache(t)
+	captureLog(t)
+	image := []byte("\x89PNG avatar")
+
+	var hits int32
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if host := r.Header.Get("X-Original-Host"); host != "avatars.githubusercontent.com" || r.URL.Path != "/octocat" {
+			t.Errorf("avatar fetched from %s%s, want avatars.githubusercontent.com/octocat", host, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(image)
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := serveGet(avatarHandler, "/avatar?login=octocat")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d", i, rec.Code, http.StatusOK)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != "image/png" {
+			t.Errorf("request %d Content-Type = %q, want image/png", i, contentType)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), image) {
+			t.Errorf("request %d body = %q, want the avatar", i, rec.Body.Bytes())
+		}
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("CDN was hit %d times, want 1", hits)
+	}
+}
+
+func TestAvatarTooLarge(t *testing.T) {
+	resetAvatarCache(t)
+	captureLog(t)
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, maxAvatarBytes+1))
+	})
+
+	rec := serveGet(avatarHandler, "/avatar?login=octocat")
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+	if len(avatars.entries) != 0 {
+		t.Error("oversized avatar was cached")
+	}
+}
+
+func TestAvatarInvalidLogin(t *testing.T) {
+	rec := serveGet(avatarHandler, "/avatar?login=../user")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// now is the clock behind time-based decisions, swappable for a fixed or
+// skewed clock.
+var now = time.Now
+
+// blackoutWindow is a maintenance period during which new logins are
+// refused. Start is inclusive, end exclusive.
+type blackoutWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// parseBlackoutWindows reads comma-separated RFC 3339 intervals of the form
+// start/end, e.g. 2026-10-20T22:00:00Z/2026-10-21T02:00:00Z.
+func parseBlackoutWindows(value string) ([]blackoutWindow, bool) {
+	var windows []blackoutWindow
+	for _, interval := range strings.Split(value, ",") {
+		interval = strings.TrimSpace(interval)
+		if interval == "" {
+			continue
+		}
+		bounds := strings.Split(interval, "/")
+		if len(bounds) != 2 {
+			return nil, false
+		}
+		start, startErr := time.Parse(time.RFC3339, bounds[0])
+		end, endErr := time.Parse(time.RFC3339, bounds[1])
+		if startErr != nil || endErr != nil || !end.After(start) {
+			return nil, false
+		}
+		windows = append(windows, blackoutWindow{start: start, end: end})
+	}
+	return windows, true
+}
+
+// formatBlackoutWindows is the inverse of parseBlackoutWindows.
+func formatBlackoutWindows(windows []blackoutWindow) string {
+	intervals := make([]string, len(windows))
+	for i, window := range windows {
+		intervals[i] = window.start.Format(time.RFC3339) + "/" + window.end.Format(time.RFC3339)
+	}
+	return strings.Join(intervals, ",")
+}
+
+// activeBlackoutWindow returns the window containing t, if any.
+func activeBlackoutWindow(windows []blackoutWindow, t time.Time) (blackoutWindow, bool) {
+	for _, window := range windows {
+		if !t.Before(window.start) && t.Before(window.end) {
+			return window, true
+		}
+	}
+	return blackoutWindow{}, false
+}
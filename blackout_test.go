@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setNow fixes the clock at t0 for the rest of the test.
+func setNow(t *testing.T, t0 time.Time) {
+	now = func() time.Time { return t0 }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestLoginBlackoutWindow(t *testing.T) {
+	setenv(t, "LOGIN_BLACKOUT_WINDOWS", "2026-10-20T22:00:00Z/2026-10-21T02:00:00Z,2026-11-01T00:00:00Z/2026-11-01T01:00:00Z")
+
+	tests := []struct {
+		name    string
+		now     string
+		blocked bool
+	}{
+		{name: "before", now: "2026-10-20T21:59:59Z"},
+		{name: "at start", now: "2026-10-20T22:00:00Z", blocked: true},
+		{name: "inside", now: "2026-10-21T01:30:00Z", blocked: true},
+		{name: "at end", now: "2026-10-21T02:00:00Z"},
+		{name: "second window", now: "2026-11-01T00:15:00+00:00", blocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, _ := time.Parse(time.RFC3339, tt.now)
+			setNow(t, current)
+
+			rec := serveGet(githubLoginHandler, "/login/github/")
+			if !tt.blocked {
+				if location := rec.Header().Get("Location"); !strings.HasPrefix(location, "https://github.com/login/oauth/authorize?") {
+					t.Errorf("login outside the window redirected to %q, want GitHub", location)
+				}
+				return
+			}
+
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+			}
+			if reason := decodeError(t, rec).Reason; reason != reasonLoginUnavailable {
+				t.Errorf("reason = %q, want %q", reason, reasonLoginUnavailable)
+			}
+			if rec.Header().Get("Retry-After") == "" {
+				t.Error("Retry-After is not set")
+			}
+		})
+	}
+}
+
+func TestParseBlackoutWindowsInvalid(t *testing.T) {
+	for _, value := range []string{
+		"2026-10-20T22:00:00Z",
+		"2026-10-21T02:00:00Z/2026-10-20T22:00:00Z",
+		"tonight/tomorrow",
+	} {
+		if _, ok := parseBlackoutWindows(value); ok {
+			t.Errorf("parseBlackoutWindows(%q) accepted an invalid window", value)
+		}
+	}
+}
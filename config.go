@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configFatal is how config getters report an unusable setting. It exits
+// by default; the config check swaps it to collect every problem instead.
+var configFatal = log.Fatal
+
+// configSetting is one environment variable the app reads. value calls the
+// setting's getter, so an invalid value is reported through configFatal,
+// and formats the effective value, defaults included.
+type configSetting struct {
+	key    string
+	secret bool
+	value  func() string
+}
+
+var configSettings = []configSetting{
+	{key: "CLIENT_ID", value: getGithubClientID},
+	{key: "CLIENT_SECRET", secret: true, value: getGithubClientSecret},
+	{key: "ROOT_BEHAVIOR", value: getRootBehavior},
+	{key: "DISABLE_DEFAULT_ROOT", value: func() string { return strconv.FormatBool(getDisableDefaultRoot()) }},
+	{key: "LOGIN_BLACKOUT_WINDOWS", value: func() string { return formatBlackoutWindows(getLoginBlackoutWindows()) }},
+	{key: "MAX_CONCURRENT_LOGINS", value: func() string { return strconv.Itoa(getMaxConcurrentLogins()) }},
+	{key: "LOGIN_QUEUE_TIMEOUT", value: func() string { return formatDuration(getLoginQueueTimeout()) }},
+	{key: "GITHUB_SCOPES", value: getGithubScopes},
+	{key: "AUTHORIZE_URL_MAX_LENGTH", value: func() string { return strconv.Itoa(getAuthorizeURLMaxLength()) }},
+	{key: "ALLOW_SIGNUP", value: func() string { return strconv.FormatBool(getAllowSignup()) }},
+	{key: "LOGGEDIN_UNAUTHORIZED", value: getLoggedinUnauthorized},
+	{key: "LOGGEDIN_UNAUTHORIZED_STATUS", value: func() string { return strconv.Itoa(getLoggedinUnauthorizedStatus()) }},
+	{key: "LOGGEDIN_UNAUTHORIZED_MESSAGE", value: getLoggedinUnauthorizedMessage},
+	{key: "AVATAR_PROXY", value: func() string { return strconv.FormatBool(getAvatarProxy()) }},
+	{key: "AVATAR_CACHE_SIZE", value: func() string { return strconv.Itoa(getAvatarCacheSize()) }},
+	{key: "AVATAR_CACHE_TTL", value: func() string { return formatDuration(getAvatarCacheTTL()) }},
+	{key: "DEBUG", value: func() string { return strconv.FormatBool(getDebugEnabled()) }},
+	{key: "GITHUB_API_VERSION", value: getGithubAPIVersion},
+	{key: "GITHUB_LOG_SAMPLE_RATE", value: func() string { return strconv.FormatFloat(getGithubLogSampleRate(), 'g', -1, 64) }},
+	{key: "SLOW_CALL_THRESHOLD", value: func() string { return formatDuration(getSlowCallThreshold()) }},
+	{key: "GITHUB_META_TTL", value: func() string { return formatDuration(getGithubMetaTTL()) }},
+	{key: "STATIC_CACHE_MAX_AGE", value: func() string { return formatDuration(getStaticCacheMaxAge()) }},
+	{key: "HSTS_MAX_AGE", value: func() string { return formatDuration(getHSTSMaxAge()) }},
+	{key: "HTTPS_REDIRECT", value: func() string { return strconv.FormatBool(getHTTPSRedirect()) }},
+	{key: "LOG_OUTPUT", value: getLogOutput},
+	{key: "LOG_FILE", value: getLogFile},
+	{key: "LOG_FORMAT", value: getLogFormat},
+	{key: "LOG_SCOPE_GRANTS", value: func() string { return strconv.FormatBool(getLogScopeGrants()) }},
+	{key: "ORG_PAGE_FAILURE", value: getOrgPageFailure},
+	{key: "ERROR_FORMAT", value: getErrorFormat},
+	{key: "VERIFY_REDIRECT_URI", value: func() string { return strconv.FormatBool(getVerifyRedirectURI()) }},
+	{key: "CHECK_CONFIG", value: func() string { return strconv.FormatBool(getCheckConfig()) }},
+}
+
+// effectiveValue is the setting as the app uses it. Secrets are reported as
+// set or unset, never by value.
+func (s configSetting) effectiveValue() string {
+	value := s.value()
+	if s.secret && value != "" {
+		return "[REDACTED]"
+	}
+	return value
+}
+
+// getEffectiveConfig reports only known settings, so unrelated env is never
+// leaked.
+func getEffectiveConfig() map[string]string {
+	config := make(map[string]string, len(configSettings))
+	for _, setting := range configSettings {
+		config[setting.key] = setting.effectiveValue()
+	}
+	return config
+}
+
+// validateConfig checks every setting, collecting the problems instead of
+// exiting on the first one. It also returns the effective config, where an
+// invalid setting shows the value it was given.
+func validateConfig() (map[string]string, []string) {
+	var problems []string
+	configFatal = func(v ...interface{}) {
+		problems = append(problems, fmt.Sprint(v...))
+	}
+	defer func() { configFatal = log.Fatal }()
+
+	config := make(map[string]string, len(configSettings))
+	for _, setting := range configSettings {
+		before := len(problems)
+		config[setting.key] = setting.effectiveValue()
+		if len(problems) > before && !setting.secret {
+			config[setting.key] = os.Getenv(setting.key)
+		}
+	}
+	return config, problems
+}
+
+// formatDuration drops the zero minutes and seconds that time.Duration's
+// String keeps, so 1h reads as 1h rather than 1h0m0s.
+func formatDuration(d time.Duration) string {
+	formatted := d.String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+	return formatted
+}
+
+// runConfigCheck validates every setting, optionally confirms the client
+// credentials with GitHub, and prints a redacted summary. It reports
+// whether the configuration is usable.
+func runConfigCheck(probeGithub bool) bool {
+	config, problems := validateConfig()
+
+	if probeGithub && len(problems) == 0 {
+		if err := probeGithubCredentials(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	fmt.Println("[ CONFIG ]")
+	for _, setting := range configSettings {
+		fmt.Printf("%s=%s\n", setting.key, config[setting.key])
+	}
+
+	if len(problems) > 0 {
+		fmt.Println("[ CONFIG INVALID ]")
+		for _, problem := range problems {
+			fmt.Println("->", problem)
+		}
+		return false
+	}
+
+	fmt.Println("[ CONFIG OK ]")
+	return true
+}
+
+// probeGithubCredentials exchanges a dummy code. GitHub checks the client
+// credentials before the code, so bad_verification_code means they work.
+func probeGithubCredentials() error {
+	ghResp, err := getGithubAccessToken("check-config-probe")
+	if err != nil && !errors.Is(err, errEmptyAccessToken) {
+		return fmt.Errorf("GitHub credentials probe failed: %v", err)
+	}
+
+	switch ghResp.Error {
+	case "bad_verification_code":
+		return nil
+	case "incorrect_client_credentials":
+		return errors.New("GitHub rejected CLIENT_ID and CLIENT_SECRET")
+	default:
+		return fmt.Errorf("unexpected GitHub credentials probe response %q", ghResp.Error)
+	}
+}
+
+// checkGithubRedirectURI asks GitHub's authorize endpoint about the
+// configured redirect_uri without following redirects. On a mismatch GitHub
+// redirects to the registered callback with error=redirect_uri_mismatch.
+// Usually GitHub just sends the anonymous probe to its login page, in which
+// case only guidance can be logged.
+func checkGithubRedirectURI() {
+	redirectURI := getGithubRedirectURI()
+	guidance := fmt.Sprintf("make sure the GitHub OAuth app's Authorization callback URL is exactly %s", redirectURI)
+
+	authorizeURL := "https://github.com/login/oauth/authorize?" + url.Values{
+		"client_id":    {getGithubClientID()},
+		"redirect_uri": {redirectURI},
+	}.Encode()
+
+	client := *githubClient
+	client.Timeout = 10 * time.Second
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Get(authorizeURL)
+	if err != nil {
+		log.Printf("[WARN] Could not check the redirect URI with GitHub (%v); %s", err, guidance)
+		return
+	}
+	resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		log.Println("[WARN] GitHub does not recognise CLIENT_ID; check it matches the OAuth app")
+	case strings.Contains(location, "error=redirect_uri_mismatch"):
+		log.Printf("[WARN] GitHub reports redirect_uri_mismatch for %s; %s", redirectURI, guidance)
+	default:
+		log.Printf("[INFO] Redirect URI could not be confirmed automatically; %s", guidance)
+	}
+}
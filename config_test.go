@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigCheckValid(t *testing.T) {
+	setenv(t, "CLIENT_ID", "client-id")
+	setenv(t, "CLIENT_SECRET", "client-secret")
+	setenv(t, "ROOT_BEHAVIOR", "login_redirect")
+
+	var ok bool
+	output := captureStdout(t, func() { ok = runConfigCheck(false) })
+	if !ok {
+		t.Fatalf("valid config was rejected:\n%s", output)
+	}
+	if !strings.Contains(output, "[ CONFIG OK ]") {
+		t.Errorf("summary does not report the config as OK:\n%s", output)
+	}
+	if !strings.Contains(output, "ROOT_BEHAVIOR=login_redirect\n") {
+		t.Errorf("summary is missing ROOT_BEHAVIOR:\n%s", output)
+	}
+	if strings.Contains(output, "client-secret") {
+		t.Errorf("summary leaks CLIENT_SECRET:\n%s", output)
+	}
+}
+
+func TestConfigCheckInvalid(t *testing.T) {
+	setenv(t, "CLIENT_ID", "client-id")
+	setenv(t, "CLIENT_SECRET", "client-secret")
+	setenv(t, "ROOT_BEHAVIOR", "redirect")
+	setenv(t, "GITHUB_META_TTL", "hourly")
+
+	var ok bool
+	output := captureStdout(t, func() { ok = runConfigCheck(false) })
+	if ok {
+		t.Fatalf("invalid config was accepted:\n%s", output)
+	}
+	for _, want := range []string{
+		"[ CONFIG INVALID ]",
+		"-> ROOT_BEHAVIOR must be either page or login_redirect",
+		"-> GITHUB_META_TTL must be a positive duration",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("summary is missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestValidateConfigAtStartup(t *testing.T) {
+	setenv(t, "ERROR_FORMAT", "rfc7807")
+
+	_, problems := validateConfig()
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "ERROR_FORMAT") {
+		t.Errorf("problems = %q, want one ERROR_FORMAT problem", problems)
+	}
+}
+
+func TestConfigCheckShowsInvalidValue(t *testing.T) {
+	setenv(t, "GITHUB_META_TTL", "hourly")
+
+	config, _ := validateConfig()
+	if got := config["GITHUB_META_TTL"]; got != "hourly" {
+		t.Errorf("GITHUB_META_TTL = %q, want the invalid value as given", got)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                       "0s",
+		10 * time.Second:        "10s",
+		5 * time.Minute:         "5m",
+		90 * time.Minute:        "1h30m",
+		180 * 24 * time.Hour:    "4320h",
+		1500 * time.Millisecond: "1.5s",
+	} {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestCheckGithubRedirectURI(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		wantLogs string
+	}{
+		{
+			name: "mismatch",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://app.example.com/callback?error=redirect_uri_mismatch&error_description=The+redirect_uri+MUST+match", http.StatusFound)
+			},
+			wantLogs: "[WARN] GitHub reports redirect_uri_mismatch for " + getGithubRedirectURI(),
+		},
+		{
+			name: "unknown client",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			wantLogs: "[WARN] GitHub does not recognise CLIENT_ID",
+		},
+		{
+			name: "unconfirmed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://github.com/login?return_to=%2Flogin%2Foauth%2Fauthorize", http.StatusFound)
+			},
+			wantLogs: "[INFO] Redirect URI could not be confirmed automatically",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged := captureLog(t)
+			mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/login/oauth/authorize" || r.URL.Query().Get("redirect_uri") != getGithubRedirectURI() {
+					t.Errorf("unexpected probe %s", r.URL)
+				}
+				tt.handler(w, r)
+			})
+
+			checkGithubRedirectURI()
+			if !strings.Contains(logged.String(), tt.wantLogs) {
+				t.Errorf("log %q does not contain %q", logged, tt.wantLogs)
+			}
+		})
+	}
+}
+
+func TestProbeGithubCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		wantErr bool
+	}{
+		{name: "valid credentials", answer: `{"error":"bad_verification_code","error_description":"The code passed is incorrect or expired."}`},
+		{name: "wrong credentials", answer: `{"error":"incorrect_client_credentials","error_description":"The client_id and/or client_secret passed are incorrect."}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged := captureLog(t)
+			mockGithub(t, jsonHandler(tt.answer))
+
+			err := probeGithubCredentials()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("probe error = %v, want error %v", err, tt.wantErr)
+			}
+			if strings.Contains(logged.String(), "[ERROR]") {
+				t.Errorf("probe logged an error: %s", logged)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+var startTime = time.Now()
+
+func debugInfoHandler(w http.ResponseWriter, r *http.Request) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	type debugMemStats struct {
+		Alloc      uint64 `json:"alloc"`
+		TotalAlloc uint64 `json:"totalAlloc"`
+		Sys        uint64 `json:"sys"`
+		HeapAlloc  uint64 `json:"heapAlloc"`
+		HeapInuse  uint64 `json:"heapInuse"`
+		NumGC      uint32 `json:"numGC"`
+	}
+
+	response := struct {
+		GoVersion  string            `json:"goVersion"`
+		Goroutines int               `json:"goroutines"`
+		MemStats   debugMemStats     `json:"memStats"`
+		Uptime     string            `json:"uptime"`
+		Config     map[string]string `json:"config"`
+	}{
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		MemStats: debugMemStats{
+			Alloc:      memStats.Alloc,
+			TotalAlloc: memStats.TotalAlloc,
+			Sys:        memStats.Sys,
+			HeapAlloc:  memStats.HeapAlloc,
+			HeapInuse:  memStats.HeapInuse,
+			NumGC:      memStats.NumGC,
+		},
+		Uptime: time.Since(startTime).Round(time.Second).String(),
+		Config: getEffectiveConfig(),
+	}
+
+	responseJSON, _ := json.MarshalIndent(response, "", "\t")
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, string(responseJSON))
+}
+
+// debugRedirectURIHandler shows the redirect_uri the login flow sends, to
+// compare against the OAuth app's registered callback URL. The callback is
+// fixed rather than derived from the request, so forwarded headers, base
+// paths and tenants do not change it; supporting them is out of scope until
+// getGithubRedirectURI takes the request into account.
+func debugRedirectURIHandler(w http.ResponseWriter, r *http.Request) {
+	response := struct {
+		RedirectURI string `json:"redirectUri"`
+	}{
+		RedirectURI: getGithubRedirectURI(),
+	}
+
+	responseJSON, _ := json.MarshalIndent(response, "", "\t")
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, string(responseJSON))
+}
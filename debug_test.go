@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDebugInfoRedactsSecrets(t *testing.T) {
+	setenv(t, "CLIENT_ID", "client-id")
+	setenv(t, "CLIENT_SECRET", "client-secret")
+	setenv(t, "ROOT_BEHAVIOR", "")
+	setenv(t, "HSTS_MAX_AGE", "")
+
+	rec := serveGet(debugInfoHandler, "/debug/info")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if strings.Contains(rec.Body.String(), "client-secret") {
+		t.Fatalf("debug info leaks CLIENT_SECRET: %s", rec.Body.String())
+	}
+
+	var info struct {
+		Config map[string]string `json:"config"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	for key, want := range map[string]string{
+		"CLIENT_SECRET": "[REDACTED]",
+		"CLIENT_ID":     "client-id",
+		"ROOT_BEHAVIOR": "page",
+		"HSTS_MAX_AGE":  "4320h",
+	} {
+		if got := info.Config[key]; got != want {
+			t.Errorf("config %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestDebugRedirectURI(t *testing.T) {
+	setenv(t, "GITHUB_SCOPES", "")
+	setenv(t, "AUTHORIZE_URL_MAX_LENGTH", "")
+
+	authorizeURL, err := getGithubAuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ := url.Parse(authorizeURL)
+	sent := parsed.Query().Get("redirect_uri")
+
+	var response struct {
+		RedirectURI string `json:"redirectUri"`
+	}
+	rec := serveGet(debugRedirectURIHandler, "/debug/redirect-uri")
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("body %q is not JSON: %v", rec.Body.String(), err)
+	}
+	if response.RedirectURI != sent {
+		t.Errorf("redirectUri = %q, want the %q the login sends", response.RedirectURI, sent)
+	}
+}
+
+func TestDebugRoutesGated(t *testing.T) {
+	for _, tt := range []struct {
+		debug string
+		want  bool
+	}{
+		{debug: "", want: false},
+		{debug: "false", want: false},
+		{debug: "true", want: true},
+	} {
+		setenv(t, "DEBUG", tt.debug)
+
+		registered := map[string]bool{}
+		for _, rt := range appRoutes() {
+			registered[rt.path] = true
+		}
+		for _, path := range []string{"/debug/info", "/debug/redirect-uri"} {
+			if registered[path] != tt.want {
+				t.Errorf("DEBUG=%q: %s registered = %v, want %v", tt.debug, path, registered[path], tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Login failure reasons. They are sent as "reason" on login errors so
+// frontends can react programmatically instead of matching messages.
+const (
+	reasonConsentDenied       = "consent_denied"
+	reasonAuthorizeFailed     = "authorize_failed"
+	reasonUnknownProvider     = "unknown_provider"
+	reasonLoginUnavailable    = "login_unavailable"
+	reasonTooManyLogins       = "too_many_logins"
+	reasonMisconfigured       = "misconfigured"
+	reasonTokenExchangeFailed = "token_exchange_failed"
+	reasonProfileFetchFailed  = "profile_fetch_failed"
+	reasonOrgFetchFailed      = "org_fetch_failed"
+)
+
+type errorResponse struct {
+	Error  string             `json:"error"`
+	Reason string             `json:"reason,omitempty"`
+	Detail string             `json:"detail,omitempty"`
+	Fields []githubFieldError `json:"fields,omitempty"`
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string, detail string) {
+	writeErrorResponse(w, status, errorResponse{Error: message, Detail: detail})
+}
+
+// writeLoginError is writeJSONError for failures of the login flow, which
+// also carry a machine-readable reason.
+func writeLoginError(w http.ResponseWriter, status int, reason string, message string, detail string) {
+	writeErrorResponse(w, status, errorResponse{Error: message, Reason: reason, Detail: detail})
+}
+
+// problemResponse is the RFC 7807 form of errorResponse, used when
+// ERROR_FORMAT=problem.
+type problemResponse struct {
+	Type   string             `json:"type"`
+	Title  string             `json:"title"`
+	Status int                `json:"status"`
+	Detail string             `json:"detail"`
+	Reason string             `json:"reason,omitempty"`
+	Fields []githubFieldError `json:"fields,omitempty"`
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, response errorResponse) {
+	// Errors are never cached, even on otherwise cacheable routes
+	w.Header().Set("Cache-Control", "no-store")
+
+	if getErrorFormat() == "problem" {
+		detail := response.Error
+		if response.Detail != "" {
+			detail += ". " + response.Detail
+		}
+		problemJSON, _ := json.Marshal(problemResponse{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: detail,
+			Reason: response.Reason,
+			Fields: response.Fields,
+		})
+
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, string(problemJSON))
+		return
+	}
+
+	responseJSON, _ := json.Marshal(response)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprint(w, string(responseJSON))
+}
+
+// writeUpstreamError reports a failed GitHub call as a 502. When GitHub
+// answered, its request ID goes in the detail so users can reference it.
+// GitHub validation failures are passed on as 422 with their field errors.
+func writeUpstreamError(w http.ResponseWriter, reason string, message string, err error) {
+	log.Println("[ERROR] "+message+":", err)
+
+	response := errorResponse{Error: message, Reason: reason}
+	var apiErr *githubAPIError
+	if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+		response.Detail = "GitHub request ID: " + apiErr.RequestID
+	}
+
+	var validationErr *githubValidationError
+	if errors.As(err, &validationErr) {
+		response.Fields = validationErr.Errors
+		writeErrorResponse(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+	writeErrorResponse(w, http.StatusBadGateway, response)
+}
+
+// acceptsHTML reports whether the client asked for HTML, as browsers do for
+// page navigations.
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func getErrorFormat() string {
+	errorFormat, exists := os.LookupEnv("ERROR_FORMAT")
+	if !exists || errorFormat == "" {
+		return "simple"
+	}
+	if errorFormat != "simple" && errorFormat != "problem" {
+		configFatal("ERROR_FORMAT must be either simple or problem")
+	}
+	return errorFormat
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestProblemErrorFormat(t *testing.T) {
+	setenv(t, "ERROR_FORMAT", "problem")
+
+	rec := httptest.NewRecorder()
+	writeLoginError(rec, http.StatusServiceUnavailable, reasonLoginUnavailable, "Login is unavailable", "Please try again later")
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", contentType)
+	}
+
+	var problem map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("body %q is not JSON: %v", rec.Body.String(), err)
+	}
+	want := map[string]interface{}{
+		"type":   "about:blank",
+		"title":  "Service Unavailable",
+		"status": float64(http.StatusServiceUnavailable),
+		"detail": "Login is unavailable. Please try again later",
+		"reason": reasonLoginUnavailable,
+	}
+	if !reflect.DeepEqual(problem, want) {
+		t.Errorf("problem = %v, want %v", problem, want)
+	}
+}
+
+func TestSimpleErrorFormat(t *testing.T) {
+	setenv(t, "ERROR_FORMAT", "")
+
+	rec := httptest.NewRecorder()
+	writeJSONError(rec, http.StatusNotFound, "Not found", "")
+
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	if body := rec.Body.String(); body != `{"error":"Not found"}` {
+		t.Errorf("body = %s, want {\"error\":\"Not found\"}", body)
+	}
+}
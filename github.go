@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// githubAPIError is returned when GitHub answers with a non-2xx status.
+type githubAPIError struct {
+	StatusCode int
+	Message    string
+	RequestID  string
+}
+
+func (e *githubAPIError) Error() string {
+	return fmt.Sprintf("GitHub returned %d %q (request id %s)", e.StatusCode, e.Message, e.RequestID)
+}
+
+// githubFieldError is one entry of the errors array GitHub sends with 422.
+type githubFieldError struct {
+	Resource string `json:"resource,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Code     string `json:"code"`
+	Message  string `json:"message,omitempty"`
+}
+
+// githubValidationError is returned for 422 Unprocessable Entity answers.
+type githubValidationError struct {
+	githubAPIError
+	Errors []githubFieldError
+}
+
+func (e *githubValidationError) Unwrap() error {
+	return &e.githubAPIError
+}
+
+// githubTransport is the RoundTripper behind every GitHub call. It pins the
+// REST API version so responses stay stable as GitHub evolves the API, and
+// logs calls: failures always, successes at GITHUB_LOG_SAMPLE_RATE, and any
+// call slower than SLOW_CALL_THRESHOLD.
+type githubTransport struct {
+	base http.RoundTripper
+}
+
+func (t *githubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set("X-GitHub-Api-Version", getGithubAPIVersion())
+
+	start := now()
+	resp, err := t.base.RoundTrip(req)
+	threshold := getSlowCallThreshold()
+	if elapsed := now().Sub(start); threshold > 0 && elapsed > threshold {
+		log.Printf("[WARN] Slow GitHub call %s %s: took %s", req.Method, req.URL, elapsed.Round(time.Millisecond))
+	}
+	if err != nil {
+		log.Printf("[ERROR] GitHub %s %s: %v", req.Method, req.URL, err)
+		return resp, err
+	}
+
+	// X-GitHub-Request-Id lets a call be matched to GitHub's own logs
+	requestID := resp.Header.Get("X-GitHub-Request-Id")
+	if resp.StatusCode >= 400 {
+		log.Printf("[ERROR] GitHub %s %s: status=%d request_id=%s", req.Method, req.URL, resp.StatusCode, requestID)
+	} else if rand.Float64() < getGithubLogSampleRate() {
+		log.Printf("[INFO] GitHub %s %s: status=%d request_id=%s", req.Method, req.URL, resp.StatusCode, requestID)
+	}
+	return resp, nil
+}
+
+var githubClient = &http.Client{Transport: &githubTransport{base: http.DefaultTransport}}
+
+// getGithubAPI performs an authenticated GET against the GitHub API.
+func getGithubAPI(url string, accessToken string) ([]byte, http.Header, error) {
+	req, reqerr := http.NewRequest("GET", url, nil)
+	if reqerr != nil {
+		return nil, nil, reqerr
+	}
+
+	authorizationHeaderValue := fmt.Sprintf("token %s", accessToken)
+	req.Header.Set("Authorization", authorizationHeaderValue)
+
+	return doGithubRequest(req)
+}
+
+// errResponseTooLarge is returned when a body exceeds the limit passed to
+// doGithubRequestLimit.
+var errResponseTooLarge = errors.New("GitHub response body is over the size limit")
+
+// doGithubRequest sends req and returns the response body. GitHub's
+// X-GitHub-Request-Id is carried on errors so a failure can be quoted in a
+// GitHub support ticket.
+func doGithubRequest(req *http.Request) ([]byte, http.Header, error) {
+	return doGithubRequestLimit(req, 0)
+}
+
+// doGithubRequestLimit is doGithubRequest reading at most maxBytes of the
+// body, so an oversized response never has to fit in memory. 0 means no
+// limit.
+func doGithubRequestLimit(req *http.Request, maxBytes int64) ([]byte, http.Header, error) {
+	resp, resperr := githubClient.Do(req)
+	if resperr != nil {
+		return nil, nil, resperr
+	}
+	defer resp.Body.Close()
+
+	requestID := resp.Header.Get("X-GitHub-Request-Id")
+
+	body := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		body = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	respbody, readerr := ioutil.ReadAll(body)
+	if readerr != nil {
+		return nil, resp.Header, readerr
+	}
+	if maxBytes > 0 && int64(len(respbody)) > maxBytes {
+		return nil, resp.Header, errResponseTooLarge
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var ghErr struct {
+			Message string             `json:"message"`
+			Errors  []githubFieldError `json:"errors"`
+		}
+		json.Unmarshal(respbody, &ghErr)
+
+		apiErr := githubAPIError{
+			StatusCode: resp.StatusCode,
+			Message:    ghErr.Message,
+			RequestID:  requestID,
+		}
+		if resp.StatusCode == http.StatusUnprocessableEntity {
+			return nil, resp.Header, &githubValidationError{githubAPIError: apiErr, Errors: ghErr.Errors}
+		}
+		return nil, resp.Header, &apiErr
+	}
+
+	return respbody, resp.Header, nil
+}
+
+// getNextPageURL returns the rel="next" target of GitHub's Link header, or
+// "" on the last page.
+func getNextPageURL(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
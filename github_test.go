@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGithubRequestIDLoggedAndSurfaced(t *testing.T) {
+	logged := captureLog(t)
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/oauth/access_token" {
+			jsonHandler(`{"access_token":"gho_test"}`)(w, r)
+			return
+		}
+		w.Header().Set("X-GitHub-Request-Id", "C0DE:5EED:1234")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"message":"Server Error"}`)
+	})
+
+	rec := serveGet(githubCallbackHandler, "/login/github/callback?code=abc")
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+	response := decodeError(t, rec)
+	if response.Reason != reasonProfileFetchFailed || response.Detail != "GitHub request ID: C0DE:5EED:1234" {
+		t.Errorf("error = %+v, want profile_fetch_failed with the GitHub request ID", response)
+	}
+	if !strings.Contains(logged.String(), "status=500 request_id=C0DE:5EED:1234") {
+		t.Errorf("request ID was not logged: %s", logged)
+	}
+}
+
+func TestGithubValidationError(t *testing.T) {
+	captureLog(t)
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-Request-Id", "F1E1:D5")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"message":"Validation Failed","errors":[{"resource":"Issue","field":"title","code":"missing_field"}]}`)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.github.com/repos/octocat/hello/issues", nil)
+	_, _, err := doGithubRequest(req)
+
+	var validationErr *githubValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want a githubValidationError", err)
+	}
+	wantFields := []githubFieldError{{Resource: "Issue", Field: "title", Code: "missing_field"}}
+	if !reflect.DeepEqual(validationErr.Errors, wantFields) {
+		t.Errorf("fields = %+v, want %+v", validationErr.Errors, wantFields)
+	}
+	var apiErr *githubAPIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID != "F1E1:D5" {
+		t.Errorf("validation error does not unwrap to the API error with its request ID")
+	}
+
+	rec := httptest.NewRecorder()
+	writeUpstreamError(rec, reasonProfileFetchFailed, "GitHub request failed", err)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if fields := decodeError(t, rec).Fields; !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("response fields = %+v, want %+v", fields, wantFields)
+	}
+}
+
+func TestGithubAPIVersionHeader(t *testing.T) {
+	captureLog(t)
+	var sent string
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Get("X-GitHub-Api-Version")
+		jsonHandler(`{"login":"octocat"}`)(w, r)
+	})
+
+	for _, tt := range []struct {
+		configured string
+		want       string
+	}{
+		{configured: "", want: "2022-11-28"},
+		{configured: "2026-03-10", want: "2026-03-10"},
+	} {
+		setenv(t, "GITHUB_API_VERSION", tt.configured)
+		if _, err := getGithubData("gho_test"); err != nil {
+			t.Fatal(err)
+		}
+		if sent != tt.want {
+			t.Errorf("GITHUB_API_VERSION=%q sent X-GitHub-Api-Version %q, want %q", tt.configured, sent, tt.want)
+		}
+	}
+}
+
+func TestGithubLogSampling(t *testing.T) {
+	mockGithub(t, jsonHandler(`{"login":"octocat"}`))
+
+	setenv(t, "GITHUB_LOG_SAMPLE_RATE", "0.25")
+	logged := captureLog(t)
+	const calls = 1000
+	for i := 0; i < calls; i++ {
+		getGithubData("gho_test")
+	}
+	// 250 expected; the bounds are over five standard deviations away
+	if sampled := strings.Count(logged.String(), "[INFO] GitHub GET"); sampled < 175 || sampled > 325 {
+		t.Errorf("%d of %d calls were logged at GITHUB_LOG_SAMPLE_RATE=0.25, want about 250", sampled, calls)
+	}
+
+	setenv(t, "GITHUB_LOG_SAMPLE_RATE", "0")
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	logged = captureLog(t)
+	for i := 0; i < 20; i++ {
+		getGithubData("gho_test")
+	}
+	if failures := strings.Count(logged.String(), "[ERROR] GitHub GET"); failures != 20 {
+		t.Errorf("%d of 20 failed calls were logged, want all of them", failures)
+	}
+	if strings.Contains(logged.String(), "[INFO]") {
+		t.Errorf("successful calls logged at GITHUB_LOG_SAMPLE_RATE=0: %s", logged)
+	}
+}
+
+// fakeClock is a clock that only moves when advanced.
+type fakeClock struct {
+	mu      sync.Mutex
+	current time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current = c.current.Add(d)
+}
+
+func TestSlowGithubCallWarning(t *testing.T) {
+	clock := &fakeClock{current: time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)}
+	now = clock.now
+	defer func() { now = time.Now }()
+
+	delay := int64(3 * time.Second)
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		clock.advance(time.Duration(atomic.LoadInt64(&delay)))
+		jsonHandler(`{"login":"octocat"}`)(w, r)
+	})
+
+	setenv(t, "SLOW_CALL_THRESHOLD", "2s")
+	logged := captureLog(t)
+	getGithubData("gho_test")
+	if want := "[WARN] Slow GitHub call GET https://api.github.com/user: took 3s"; !strings.Contains(logged.String(), want) {
+		t.Errorf("log %q does not contain %q", logged, want)
+	}
+
+	atomic.StoreInt64(&delay, int64(time.Second))
+	logged = captureLog(t)
+	getGithubData("gho_test")
+	if strings.Contains(logged.String(), "Slow GitHub call") {
+		t.Errorf("call under the threshold was reported as slow: %s", logged)
+	}
+
+	atomic.StoreInt64(&delay, int64(time.Minute))
+	setenv(t, "SLOW_CALL_THRESHOLD", "0")
+	logged = captureLog(t)
+	getGithubData("gho_test")
+	if strings.Contains(logged.String(), "Slow GitHub call") {
+		t.Errorf("slow call reported with SLOW_CALL_THRESHOLD=0: %s", logged)
+	}
+}
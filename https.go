@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// httpsHandler adds Strict-Transport-Security to HTTPS responses and, when
+// HTTPS_REDIRECT is set, sends plain-HTTP requests to their HTTPS URL.
+// Requests for localhost are left alone so local development keeps working.
+func httpsHandler(next http.Handler) http.Handler {
+	hstsMaxAge := getHSTSMaxAge()
+	hstsValue := fmt.Sprintf("max-age=%d", int(hstsMaxAge.Seconds()))
+	redirect := getHTTPSRedirect()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isLocalhost(r.Host) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !isHTTPS(r) {
+			if redirect {
+				http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if hstsMaxAge > 0 {
+			w.Header().Set("Strict-Transport-Security", hstsValue)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isHTTPS reports whether the client connection is HTTPS, either directly or
+// as reported by a TLS-terminating proxy's X-Forwarded-Proto.
+func isHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	proto := strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0]
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
+
+func isLocalhost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveHTTPS(host string, forwardedProto string) *httptest.ResponseRecorder {
+	handler := httpsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/login/github/?next=1", nil)
+	req.Host = host
+	if forwardedProto != "" {
+		req.Header.Set("X-Forwarded-Proto", forwardedProto)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHSTS(t *testing.T) {
+	setenv(t, "HSTS_MAX_AGE", "")
+	setenv(t, "HTTPS_REDIRECT", "")
+
+	if hsts := serveHTTPS("auth.example.com", "https").Header().Get("Strict-Transport-Security"); hsts != "max-age=15552000" {
+		t.Errorf("HSTS over HTTPS = %q, want max-age=15552000", hsts)
+	}
+	for _, host := range []string{"localhost:3000", "127.0.0.1:3000", "[::1]:3000"} {
+		if hsts := serveHTTPS(host, "https").Header().Get("Strict-Transport-Security"); hsts != "" {
+			t.Errorf("HSTS on %s = %q, want none", host, hsts)
+		}
+	}
+	if hsts := serveHTTPS("auth.example.com", "").Header().Get("Strict-Transport-Security"); hsts != "" {
+		t.Errorf("HSTS over plain HTTP = %q, want none", hsts)
+	}
+
+	setenv(t, "HSTS_MAX_AGE", "0")
+	if hsts := serveHTTPS("auth.example.com", "https").Header().Get("Strict-Transport-Security"); hsts != "" {
+		t.Errorf("HSTS with HSTS_MAX_AGE=0 = %q, want none", hsts)
+	}
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	setenv(t, "HTTPS_REDIRECT", "true")
+
+	rec := serveHTTPS("auth.example.com", "http")
+	if rec.Code != http.StatusPermanentRedirect {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusPermanentRedirect)
+	}
+	if location := rec.Header().Get("Location"); location != "https://auth.example.com/login/github/?next=1" {
+		t.Errorf("redirected to %q, want the HTTPS URL", location)
+	}
+
+	if rec := serveHTTPS("localhost:3000", ""); rec.Code != http.StatusOK {
+		t.Errorf("localhost status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
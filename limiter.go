@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// loginSlots bounds how many callbacks can be talking to GitHub at once, to
+// keep login storms inside GitHub's rate limit. nil means unlimited.
+var loginSlots chan struct{}
+
+func initLoginSlots(maxConcurrentLogins int) {
+	if maxConcurrentLogins > 0 {
+		loginSlots = make(chan struct{}, maxConcurrentLogins)
+	}
+}
+
+// acquireLoginSlot waits up to LOGIN_QUEUE_TIMEOUT for a free slot. It gives
+// up early if the client goes away while queued.
+func acquireLoginSlot(ctx context.Context) bool {
+	if loginSlots == nil {
+		return true
+	}
+
+	// A free slot must win outright; racing it against an expired timer
+	// would turn away logins at random when LOGIN_QUEUE_TIMEOUT is 0
+	select {
+	case loginSlots <- struct{}{}:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(getLoginQueueTimeout())
+	defer timer.Stop()
+
+	select {
+	case loginSlots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return false
+	}
+}
+
+func releaseLoginSlot() {
+	if loginSlots != nil {
+		<-loginSlots
+	}
+}
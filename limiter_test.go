@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func useLoginSlots(t *testing.T, maxConcurrentLogins int) {
+	initLoginSlots(maxConcurrentLogins)
+	t.Cleanup(func() { loginSlots = nil })
+}
+
+func TestLoginLimitUnderConcurrentCallbacks(t *testing.T) {
+	const maxLogins = 2
+	useLoginSlots(t, maxLogins)
+	setenv(t, "LOGIN_QUEUE_TIMEOUT", "10s")
+	captureLog(t)
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	mockGithubLogin(t, orgPages(`[{"login":"a"}]`))
+	transport := githubClient.Transport.(*githubTransport)
+	mocked := transport.base
+	transport.base = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		return mocked.RoundTrip(req)
+	})
+	t.Cleanup(func() { transport.base = mocked })
+
+	var wg sync.WaitGroup
+	statuses := make([]int, 8)
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses[i] = serveGet(githubCallbackHandler, "/login/github/callback?code=abc").Code
+		}(i)
+	}
+	wg.Wait()
+
+	for i, status := range statuses {
+		if status != http.StatusSeeOther {
+			t.Errorf("callback %d status = %d, want %d", i, status, http.StatusSeeOther)
+		}
+	}
+	if maxInFlight != maxLogins {
+		t.Errorf("at most %d GitHub calls were in flight, want %d", maxInFlight, maxLogins)
+	}
+}
+
+func TestLoginLimitRejectsWhenFull(t *testing.T) {
+	useLoginSlots(t, 1)
+	setenv(t, "LOGIN_QUEUE_TIMEOUT", "10ms")
+	captureLog(t)
+
+	if !acquireLoginSlot(context.Background()) {
+		t.Fatal("first login was refused")
+	}
+	defer releaseLoginSlot()
+
+	rec := serveGet(githubCallbackHandler, "/login/github/callback?code=abc")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if reason := decodeError(t, rec).Reason; reason != reasonTooManyLogins {
+		t.Errorf("reason = %q, want %q", reason, reasonTooManyLogins)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After is not set")
+	}
+}
+
+func TestLoginLimitZeroQueueTimeout(t *testing.T) {
+	useLoginSlots(t, 5)
+	setenv(t, "LOGIN_QUEUE_TIMEOUT", "0")
+
+	for i := 0; i < 1000; i++ {
+		if !acquireLoginSlot(context.Background()) {
+			t.Fatalf("login %d was refused with free slots", i)
+		}
+		releaseLoginSlot()
+	}
+
+	for i := 0; i < 5; i++ {
+		acquireLoginSlot(context.Background())
+	}
+	if acquireLoginSlot(context.Background()) {
+		t.Error("login was admitted with every slot taken")
+	}
+}
+
+func TestLoginLimitCancelledWhileQueued(t *testing.T) {
+	useLoginSlots(t, 1)
+	setenv(t, "LOGIN_QUEUE_TIMEOUT", "1h")
+	acquireLoginSlot(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if acquireLoginSlot(ctx) {
+		t.Error("cancelled login was admitted")
+	}
+}
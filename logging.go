@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// jsonLogWriter rewrites each line from the log package as a JSON object.
+// A leading [INFO], [WARN] or [ERROR] tag becomes the level field.
+type jsonLogWriter struct {
+	out io.Writer
+}
+
+func (w jsonLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	level := "info"
+	for _, tag := range []string{"INFO", "WARN", "ERROR"} {
+		if strings.HasPrefix(msg, "["+tag+"] ") {
+			level = strings.ToLower(tag)
+			msg = strings.TrimPrefix(msg, "["+tag+"] ")
+			break
+		}
+	}
+
+	line, _ := json.Marshal(struct {
+		Time  string `json:"time"`
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}{
+		Time:  now().UTC().Format(time.RFC3339Nano),
+		Level: level,
+		Msg:   msg,
+	})
+	if _, err := w.out.Write(append(line, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// configureLogging points the standard logger at LOG_OUTPUT in LOG_FORMAT.
+// A log file is opened for append and closed when the process is stopped.
+func configureLogging() {
+	var out io.Writer
+	switch getLogOutput() {
+	case "stdout":
+		out = os.Stdout
+	case "file":
+		logFile, err := os.OpenFile(getLogFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("[ERROR] Cannot open LOG_FILE: ", err)
+		}
+		closeOnShutdown(logFile)
+		out = logFile
+	default:
+		out = os.Stderr
+	}
+
+	if getLogFormat() == "json" {
+		log.SetFlags(0)
+		log.SetOutput(jsonLogWriter{out: out})
+		return
+	}
+	log.SetOutput(out)
+}
+
+// closeOnShutdown closes logFile on SIGINT or SIGTERM, then re-raises the
+// signal with its default handling so the process exits as it would have
+// without a log file. Signals the process was started ignoring are left
+// alone, since re-raising them would not stop it.
+func closeOnShutdown(logFile *os.File) {
+	signals := make(chan os.Signal, 1)
+	for _, sig := range []os.Signal{os.Interrupt, syscall.SIGTERM} {
+		if !signal.Ignored(sig) {
+			signal.Notify(signals, sig)
+		}
+	}
+	go func() {
+		sig := <-signals
+		logFile.Close()
+		signal.Reset(sig)
+		if self, err := os.FindProcess(os.Getpid()); err == nil {
+			self.Signal(sig)
+		}
+	}()
+}
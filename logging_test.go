@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// resetLogging puts the standard logger back once the test is done.
+func resetLogging(t *testing.T) {
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	})
+}
+
+type jsonLogLine struct {
+	Time  string `json:"time"`
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+func decodeLogLines(t *testing.T, output string) []jsonLogLine {
+	t.Helper()
+	var lines []jsonLogLine
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var decoded jsonLogLine
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+func TestLogFormat(t *testing.T) {
+	resetLogging(t)
+	setenv(t, "LOG_OUTPUT", "stdout")
+	now = func() time.Time { return time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	setenv(t, "LOG_FORMAT", "json")
+	output := captureStdout(t, func() {
+		configureLogging()
+		log.Println("[WARN] Slow GitHub call")
+		log.Println("Untagged line")
+	})
+	lines := decodeLogLines(t, output)
+	want := []jsonLogLine{
+		{Time: "2026-10-14T12:00:00Z", Level: "warn", Msg: "Slow GitHub call"},
+		{Time: "2026-10-14T12:00:00Z", Level: "info", Msg: "Untagged line"},
+	}
+	if len(lines) != len(want) || lines[0] != want[0] || lines[1] != want[1] {
+		t.Errorf("json log lines = %+v, want %+v", lines, want)
+	}
+
+	setenv(t, "LOG_FORMAT", "text")
+	output = captureStdout(t, func() {
+		configureLogging()
+		log.Println("[WARN] Slow GitHub call")
+	})
+	if strings.HasPrefix(output, "{") || !strings.HasSuffix(output, "[WARN] Slow GitHub call\n") {
+		t.Errorf("text log output = %q, want a plain line", output)
+	}
+}
+
+func TestLogOutput(t *testing.T) {
+	resetLogging(t)
+	setenv(t, "LOG_FORMAT", "text")
+
+	for _, tt := range []struct {
+		output string
+		to     **os.File
+		other  **os.File
+	}{
+		{output: "stdout", to: &os.Stdout, other: &os.Stderr},
+		{output: "stderr", to: &os.Stderr, other: &os.Stdout},
+	} {
+		setenv(t, "LOG_OUTPUT", tt.output)
+		var written string
+		otherWritten := captureOutput(t, tt.other, func() {
+			written = captureOutput(t, tt.to, func() {
+				configureLogging()
+				log.Println("routed line")
+			})
+		})
+		if !strings.Contains(written, "routed line") {
+			t.Errorf("LOG_OUTPUT=%s: log line not written there", tt.output)
+		}
+		if otherWritten != "" {
+			t.Errorf("LOG_OUTPUT=%s: log line also written elsewhere: %q", tt.output, otherWritten)
+		}
+	}
+}
+
+func TestLogOutputFileAppends(t *testing.T) {
+	resetLogging(t)
+	dir, err := ioutil.TempDir("", "gauth-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	logFile := filepath.Join(dir, "gauth.log")
+	if err := ioutil.WriteFile(logFile, []byte("earlier line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setenv(t, "LOG_OUTPUT", "file")
+	setenv(t, "LOG_FILE", logFile)
+	setenv(t, "LOG_FORMAT", "text")
+
+	configureLogging()
+	log.Println("appended line")
+
+	written, _ := ioutil.ReadFile(logFile)
+	if !strings.HasPrefix(string(written), "earlier line\n") || !strings.Contains(string(written), "appended line") {
+		t.Errorf("log file = %q, want the new line appended", written)
+	}
+}
+
+func TestFailuresLogAtErrorLevel(t *testing.T) {
+	resetLogging(t)
+	setenv(t, "LOG_OUTPUT", "stdout")
+	setenv(t, "LOG_FORMAT", "json")
+
+	output := captureStdout(t, func() {
+		configureLogging()
+		writeUpstreamError(httptest.NewRecorder(), reasonProfileFetchFailed, "GitHub user request failed", errors.New("connection reset"))
+	})
+	lines := decodeLogLines(t, output)
+	if len(lines) != 1 || lines[0].Level != "error" || lines[0].Msg != "GitHub user request failed: connection reset" {
+		t.Errorf("upstream failure logged as %+v, want one error line", lines)
+	}
+}
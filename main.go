@@ -3,11 +3,15 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -19,64 +23,246 @@ func init() {
 }
 
 func main() {
-	http.HandleFunc("/", rootHandler)
-	http.HandleFunc("/login/github/", githubLoginHandler)
-	http.HandleFunc("/login/github/callback", githubCallbackHandler)
-	http.HandleFunc("/loggedin", func(w http.ResponseWriter, r *http.Request) {
-		githubData := r.URL.Query().Get("githubData")
-		loggedinHandler(w, r, githubData)
-	})
+	checkConfig := flag.Bool("check-config", false, "validate the configuration and exit")
+	probeGithub := flag.Bool("probe-github", false, "with -check-config, also verify CLIENT_ID and CLIENT_SECRET with GitHub")
+	flag.Parse()
+
+	if *checkConfig || getCheckConfig() {
+		if !runConfigCheck(*probeGithub) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Most settings are only read by the requests that need them, so a bad
+	// value would otherwise stop the server long after it started
+	if _, problems := validateConfig(); len(problems) > 0 {
+		log.Fatal("Invalid configuration: ", strings.Join(problems, "; "))
+	}
+
+	configureLogging()
 
-	fmt.Println("[ UP ON PORT 3000 ]")
-	log.Panic(http.ListenAndServe(":3000", nil))
+	for _, rt := range appRoutes() {
+		handle(http.DefaultServeMux, rt)
+	}
+
+	initLoginSlots(getMaxConcurrentLogins())
+	if getVerifyRedirectURI() {
+		go checkGithubRedirectURI()
+	}
+	startGithubMetaRefresher(getGithubMetaTTL())
+
+	log.Println("[INFO] Up on port 3000")
+	log.Panic(http.ListenAndServe(":3000", httpsHandler(trailingSlashHandler(http.DefaultServeMux))))
+}
+
+// appRoutes is every route the server registers, optional ones included
+// only when enabled.
+func appRoutes() []route {
+	get := []string{http.MethodGet}
+	routes := []route{
+		{path: "/", methods: get, handler: rootHandler},
+		{path: "/login", methods: get, handler: loginHandler},
+		{path: "/login/github/", methods: get, handler: githubLoginHandler},
+		{path: "/login/github/callback", methods: get, handler: githubCallbackHandler},
+		{path: "/loggedin", methods: get, handler: func(w http.ResponseWriter, r *http.Request) {
+			githubData := r.URL.Query().Get("githubData")
+			loggedinHandler(w, r, githubData)
+		}},
+		{path: "/meta", methods: get, cacheable: true, handler: metaHandler},
+		{path: "/readyz", methods: get, handler: readyzHandler},
+	}
+	if getAvatarProxy() {
+		routes = append(routes, route{path: "/avatar", methods: get, cacheable: true, handler: avatarHandler})
+	}
+	if getDebugEnabled() {
+		routes = append(routes,
+			route{path: "/debug/info", methods: get, handler: debugInfoHandler},
+			route{path: "/debug/redirect-uri", methods: get, handler: debugRedirectURIHandler},
+		)
+	}
+	return routes
 }
 
 func rootHandler(w http.ResponseWriter, r *http.Request) {
+	if getDisableDefaultRoot() {
+		// A separate frontend owns the landing page
+		notFoundHandler(w, r)
+		return
+	}
+
+	if getRootBehavior() == "login_redirect" {
+		// Headless deployments skip the landing page entirely
+		githubLoginHandler(w, r)
+		return
+	}
+
 	fmt.Fprintf(w, `<a href="/login/github/">LOGIN</a>`)
 }
 
 func loggedinHandler(w http.ResponseWriter, r *http.Request, githubData string) {
 	if githubData == "" {
-		// Unauthorized response
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		fmt.Fprintf(w, `{"error": "Unauthorized"}`)
+		// Unauthorized response, browsers can be sent to log in instead
+		if getLoggedinUnauthorized() == "redirect" && acceptsHTML(r) {
+			http.Redirect(w, r, "/login/github/", http.StatusFound)
+			return
+		}
+		writeJSONError(w, getLoggedinUnauthorizedStatus(), getLoggedinUnauthorizedMessage(), "")
 		return
 	}
 
 	// Process authorized response
-	w.Header().Set("Content-Type", "application/json")
-
 	var prettyJSON bytes.Buffer
 	parserr := json.Indent(&prettyJSON, []byte(githubData), "", "\t")
 	if parserr != nil {
 		// JSON parse error
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"error": "JSON parse error"}`)
+		writeJSONError(w, http.StatusInternalServerError, "JSON parse error", "")
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	fmt.Fprintf(w, string(prettyJSON.Bytes()))
 }
 
+// loginProviders maps the provider query parameter of /login to the
+// provider's own login flow.
+var loginProviders = map[string]http.HandlerFunc{
+	"github": githubLoginHandler,
+}
+
+// loginHandler is a stable entry point for deep links: /login?provider=github
+// starts that provider's login regardless of its own route.
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	provider := r.URL.Query().Get("provider")
+	providerLogin, known := loginProviders[provider]
+	if !known {
+		writeLoginError(w, http.StatusBadRequest, reasonUnknownProvider, "Unknown login provider", fmt.Sprintf("provider %q is not configured", provider))
+		return
+	}
+	providerLogin(w, r)
+}
+
 func githubLoginHandler(w http.ResponseWriter, r *http.Request) {
-	githubClientID := getGithubClientID()
-	redirectURL := fmt.Sprintf("https://github.com/login/oauth/authorize?client_id=%s&redirect_uri=%s&scope=user,read:org", githubClientID, "http://localhost:3000/login/github/callback")
+	currentTime := now()
+	if window, inBlackout := activeBlackoutWindow(getLoginBlackoutWindows(), currentTime); inBlackout {
+		w.Header().Set("Retry-After", strconv.Itoa(int(window.end.Sub(currentTime).Seconds())+1))
+		writeLoginError(w, http.StatusServiceUnavailable, reasonLoginUnavailable, "Login is unavailable during scheduled maintenance", "Please try again after "+window.end.UTC().Format(time.RFC3339))
+		return
+	}
+
+	redirectURL, err := getGithubAuthorizeURL()
+	if err != nil {
+		log.Println("[ERROR] Cannot start GitHub login:", err)
+		writeLoginError(w, http.StatusInternalServerError, reasonMisconfigured, "Login is misconfigured", "The GitHub login URL is too long; reduce the requested scopes")
+		return
+	}
 	http.Redirect(w, r, redirectURL, 301)
 }
 
+// getGithubAuthorizeURL builds the GitHub authorize URL. URLs longer than
+// AUTHORIZE_URL_MAX_LENGTH are refused rather than risk a browser or proxy
+// truncating them into a broken redirect.
+func getGithubAuthorizeURL() (string, error) {
+	githubClientID := getGithubClientID()
+	redirectURL := fmt.Sprintf("https://github.com/login/oauth/authorize?client_id=%s&redirect_uri=%s&scope=%s", githubClientID, getGithubRedirectURI(), getGithubScopes())
+	if !getAllowSignup() {
+		redirectURL += "&allow_signup=false"
+	}
+
+	if maxLength := getAuthorizeURLMaxLength(); len(redirectURL) > maxLength {
+		return "", fmt.Errorf("authorize URL is %d bytes, over the %d byte AUTHORIZE_URL_MAX_LENGTH", len(redirectURL), maxLength)
+	}
+	return redirectURL, nil
+}
+
 func githubCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if authorizeError := r.URL.Query().Get("error"); authorizeError != "" {
+		log.Printf("[WARN] GitHub authorization failed: error=%q description=%q", authorizeError, r.URL.Query().Get("error_description"))
+		response, known := authorizeErrors[authorizeError]
+		if !known {
+			response = unknownAuthorizeError
+		}
+		if authorizeError == "access_denied" && !getAllowSignup() {
+			// With allow_signup=false, users without an account end up here
+			response.message = "Login is limited to existing GitHub accounts. Sign in to GitHub with an existing account and try again"
+		}
+		writeLoginError(w, response.status, response.reason, response.message, "")
+		return
+	}
+
 	code := r.URL.Query().Get("code")
-	githubAccessToken := getGithubAccessToken(code)
-	githubData := getGithubData(githubAccessToken)
-	githubOrgs := getGithubOrganizations(githubAccessToken)
+	if code == "" {
+		// Parameters may have arrived in the fragment, which only the browser sees
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, fragmentBootstrapHTML)
+		return
+	}
+
+	// The slot covers every GitHub call the callback makes, not just the exchange
+	if !acquireLoginSlot(r.Context()) {
+		w.Header().Set("Retry-After", "1")
+		writeLoginError(w, http.StatusServiceUnavailable, reasonTooManyLogins, "Too many logins in progress", "Please try again in a moment")
+		return
+	}
+	defer releaseLoginSlot()
+
+	githubToken, err := getGithubAccessToken(code)
+	if errors.Is(err, errUnknownClientID) {
+		writeLoginError(w, http.StatusBadGateway, reasonMisconfigured, "GitHub OAuth app not found", "The server's GitHub CLIENT_ID does not match a GitHub OAuth app")
+		return
+	}
+	if errors.Is(err, errEmptyAccessToken) {
+		// The config probe expects an empty token, so only the callback logs it
+		switch githubToken.Error {
+		case "bad_verification_code":
+			// Usually a reloaded callback page resubmitting a spent code
+			log.Printf("[WARN] GitHub rejected the authorization code as expired or already used (description=%q)", githubToken.ErrorDescription)
+			writeLoginError(w, http.StatusBadRequest, reasonTokenExchangeFailed, "The login code expired or was already used", "Please restart the login")
+		case "incorrect_client_credentials":
+			log.Printf("[ERROR] GitHub rejected the client credentials (description=%q); check CLIENT_SECRET matches the OAuth app", githubToken.ErrorDescription)
+			writeLoginError(w, http.StatusBadGateway, reasonTokenExchangeFailed, "GitHub returned no access token", "The server's GitHub OAuth credentials are misconfigured")
+		default:
+			log.Printf("[ERROR] GitHub token exchange returned no access token (error=%q description=%q)", githubToken.Error, githubToken.ErrorDescription)
+			writeLoginError(w, http.StatusBadGateway, reasonTokenExchangeFailed, "GitHub returned no access token", "The login could not be completed. Please try again")
+		}
+		return
+	}
+	if err != nil {
+		writeUpstreamError(w, reasonTokenExchangeFailed, "GitHub token exchange failed", err)
+		return
+	}
+	githubAccessToken := githubToken.AccessToken
+
+	githubData, err := getGithubData(githubAccessToken)
+	if err != nil {
+		writeUpstreamError(w, reasonProfileFetchFailed, "GitHub user request failed", err)
+		return
+	}
+
+	var warnings []string
+	githubOrgs, err := getGithubOrganizations(githubAccessToken)
+	if err != nil {
+		// A failed first page leaves nothing worth returning
+		if len(githubOrgs) == 0 || getOrgPageFailure() == "fail" {
+			writeUpstreamError(w, reasonOrgFetchFailed, "GitHub organizations request failed", err)
+			return
+		}
+		log.Println("[WARN] Returning partial organization list:", err)
+		warnings = append(warnings, "organization list is incomplete")
+	}
+
+	if getLogScopeGrants() {
+		logScopeGrant(githubData, githubToken.Scope)
+	}
 
 	response := struct {
 		GithubData string   `json:"githubData"`
 		GithubOrgs []string `json:"githubOrgs"`
+		Warnings   []string `json:"warnings,omitempty"`
 	}{
 		GithubData: githubData,
 		GithubOrgs: githubOrgs,
+		Warnings:   warnings,
 	}
 
 	responseJSON, _ := json.Marshal(response)
@@ -84,26 +270,85 @@ func githubCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/loggedin?githubData="+string(responseJSON), http.StatusSeeOther)
 }
 
-func getGithubData(accessToken string) string {
-	req, reqerr := http.NewRequest("GET", "https://api.github.com/user", nil)
-	if reqerr != nil {
-		log.Panic("API Request creation failed")
-	}
+// authorizeErrorResponse is how the callback answers an error code GitHub
+// sent back from the authorize step.
+type authorizeErrorResponse struct {
+	status  int
+	reason  string
+	message string
+}
+
+var authorizeErrors = map[string]authorizeErrorResponse{
+	"access_denied": {
+		status:  http.StatusUnauthorized,
+		reason:  reasonConsentDenied,
+		message: "GitHub authorization was denied. Approve the request on GitHub to log in",
+	},
+	"application_suspended": {
+		status:  http.StatusServiceUnavailable,
+		reason:  reasonMisconfigured,
+		message: "This application has been suspended on GitHub, so logging in is not possible right now",
+	},
+	"redirect_uri_mismatch": {
+		status:  http.StatusInternalServerError,
+		reason:  reasonMisconfigured,
+		message: "The login callback URL does not match the one registered with GitHub",
+	},
+}
 
-	authorizationHeaderValue := fmt.Sprintf("token %s", accessToken)
-	req.Header.Set("Authorization", authorizationHeaderValue)
+var unknownAuthorizeError = authorizeErrorResponse{
+	status:  http.StatusUnauthorized,
+	reason:  reasonAuthorizeFailed,
+	message: "GitHub authorization was not completed",
+}
 
-	resp, resperr := http.DefaultClient.Do(req)
-	if resperr != nil {
-		log.Panic("Request failed")
+// fragmentBootstrapHTML re-submits callback parameters delivered in the URL
+// fragment (#code=...&state=...) as a query string, so they reach the
+// normal query-based callback path. Without a fragment it just reports the
+// missing code.
+const fragmentBootstrapHTML = `<!DOCTYPE html>
+<html>
+<head><title>Completing login</title></head>
+<body>
+<p id="message">Completing login...</p>
+<script>
+if (window.location.hash.length > 1) {
+	window.location.replace(window.location.pathname + "?" + window.location.hash.substring(1));
+} else {
+	document.getElementById("message").innerHTML = 'Missing authorization code. <a href="/login/github/">LOGIN</a>';
+}
+</script>
+<noscript>Missing authorization code. <a href="/login/github/">LOGIN</a></noscript>
+</body>
+</html>`
+
+func getGithubData(accessToken string) (string, error) {
+	respbody, _, err := getGithubAPI("https://api.github.com/user", accessToken)
+	if err != nil {
+		return "", err
 	}
 
-	respbody, _ := ioutil.ReadAll(resp.Body)
+	return string(respbody), nil
+}
 
-	return string(respbody)
+type githubAccessTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
 }
 
-func getGithubAccessToken(code string) string {
+// errEmptyAccessToken means GitHub answered 200 but without a token. The
+// error field says why: incorrect_client_credentials for a CLIENT_SECRET
+// mismatch, bad_verification_code for an expired or reused code.
+var errEmptyAccessToken = errors.New("GitHub token exchange returned an empty access token")
+
+// errUnknownClientID means GitHub answered the token exchange with Not Found,
+// which it does when CLIENT_ID does not belong to any OAuth app.
+var errUnknownClientID = errors.New("GitHub token endpoint returned Not Found")
+
+func getGithubAccessToken(code string) (githubAccessTokenResponse, error) {
 	clientID := getGithubClientID()
 	clientSecret := getGithubClientSecret()
 
@@ -115,37 +360,60 @@ func getGithubAccessToken(code string) string {
 
 	requestJSON, _ := json.Marshal(requestBodyMap)
 
+	var ghResp githubAccessTokenResponse
+
 	req, reqErr := http.NewRequest("POST", "https://github.com/login/oauth/access_token", bytes.NewBuffer(requestJSON))
 	if reqErr != nil {
-		log.Panic("Request creation failed:", reqErr)
+		return ghResp, reqErr
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	resp, respErr := http.DefaultClient.Do(req)
+	respBody, _, respErr := doGithubRequest(req)
+	var apiErr *githubAPIError
+	if errors.As(respErr, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		log.Printf("[ERROR] GitHub token endpoint returned 404 (request id %s); check CLIENT_ID matches the OAuth app", apiErr.RequestID)
+		return ghResp, errUnknownClientID
+	}
 	if respErr != nil {
-		log.Panic("Request failed:", respErr)
+		return ghResp, respErr
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := ioutil.ReadAll(resp.Body)
+	json.Unmarshal(respBody, &ghResp)
 
-	type githubAccessTokenResponse struct {
-		AccessToken string `json:"access_token"`
-		TokenType   string `json:"token_type"`
-		Scope       string `json:"scope"`
+	if ghResp.Error == "Not Found" {
+		log.Println("[ERROR] GitHub token endpoint returned Not Found; check CLIENT_ID matches the OAuth app")
+		return ghResp, errUnknownClientID
 	}
 
-	var ghResp githubAccessTokenResponse
-	json.Unmarshal(respBody, &ghResp)
+	if ghResp.AccessToken == "" {
+		return ghResp, errEmptyAccessToken
+	}
+
+	return ghResp, nil
+}
 
-	return ghResp.AccessToken
+// logScopeGrant records which scopes the user actually granted, which may be
+// fewer than requested. The token itself is never logged.
+func logScopeGrant(githubData string, scope string) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	json.Unmarshal([]byte(githubData), &user)
+
+	log.Printf("[INFO] scope grant: user=%s scopes=%q time=%s", user.Login, scope, now().UTC().Format(time.RFC3339))
+}
+
+// getGithubRedirectURI is the callback sent to GitHub. It must match the
+// callback URL registered on the OAuth app exactly.
+func getGithubRedirectURI() string {
+	return "http://localhost:3000/login/github/callback"
 }
 
 func getGithubClientID() string {
 	githubClientID, exists := os.LookupEnv("CLIENT_ID")
 	if !exists {
-		log.Fatal("Github Client ID not defined in .env file")
+		configFatal("Github Client ID not defined in .env file")
 	}
 	return githubClientID
 }
@@ -153,39 +421,382 @@ func getGithubClientID() string {
 func getGithubClientSecret() string {
 	githubClientSecret, exists := os.LookupEnv("CLIENT_SECRET")
 	if !exists {
-		log.Fatal("Github Client Secret not defined in .env file")
+		configFatal("Github Client Secret not defined in .env file")
 	}
 	return githubClientSecret
 }
 
-func getGithubOrganizations(accessToken string) []string {
-	req, reqerr := http.NewRequest("GET", "https://api.github.com/user/orgs", nil)
-	if reqerr != nil {
-		log.Panic("API Request creation failed")
+func getRootBehavior() string {
+	rootBehavior, exists := os.LookupEnv("ROOT_BEHAVIOR")
+	if !exists || rootBehavior == "" {
+		return "page"
+	}
+	if rootBehavior != "page" && rootBehavior != "login_redirect" {
+		configFatal("ROOT_BEHAVIOR must be either page or login_redirect")
 	}
+	return rootBehavior
+}
 
-	authorizationHeaderValue := fmt.Sprintf("token %s", accessToken)
-	req.Header.Set("Authorization", authorizationHeaderValue)
+func getDisableDefaultRoot() bool {
+	disableDefaultRoot, exists := os.LookupEnv("DISABLE_DEFAULT_ROOT")
+	if !exists || disableDefaultRoot == "" {
+		return false
+	}
+	disabled, err := strconv.ParseBool(disableDefaultRoot)
+	if err != nil {
+		configFatal("DISABLE_DEFAULT_ROOT must be a boolean")
+	}
+	return disabled
+}
 
-	resp, resperr := http.DefaultClient.Do(req)
-	if resperr != nil {
-		log.Panic("Request failed")
+func getAvatarProxy() bool {
+	avatarProxy, exists := os.LookupEnv("AVATAR_PROXY")
+	if !exists || avatarProxy == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(avatarProxy)
+	if err != nil {
+		configFatal("AVATAR_PROXY must be a boolean")
 	}
+	return enabled
+}
 
-	defer resp.Body.Close()
-	respbody, _ := ioutil.ReadAll(resp.Body)
+func getAvatarCacheSize() int {
+	cacheSize, exists := os.LookupEnv("AVATAR_CACHE_SIZE")
+	if !exists || cacheSize == "" {
+		return 256
+	}
+	size, err := strconv.Atoi(cacheSize)
+	if err != nil || size <= 0 {
+		configFatal("AVATAR_CACHE_SIZE must be a positive integer")
+	}
+	return size
+}
 
+func getAvatarCacheTTL() time.Duration {
+	cacheTTL, exists := os.LookupEnv("AVATAR_CACHE_TTL")
+	if !exists || cacheTTL == "" {
+		return time.Hour
+	}
+	ttl, err := time.ParseDuration(cacheTTL)
+	if err != nil || ttl <= 0 {
+		configFatal("AVATAR_CACHE_TTL must be a positive duration, e.g. 1h")
+	}
+	return ttl
+}
+
+func getDebugEnabled() bool {
+	debug, exists := os.LookupEnv("DEBUG")
+	if !exists || debug == "" {
+		return false
+	}
+	debugEnabled, err := strconv.ParseBool(debug)
+	if err != nil {
+		configFatal("DEBUG must be a boolean")
+	}
+	return debugEnabled
+}
+
+func getLogOutput() string {
+	logOutput, exists := os.LookupEnv("LOG_OUTPUT")
+	if !exists || logOutput == "" {
+		return "stderr"
+	}
+	if logOutput != "stdout" && logOutput != "stderr" && logOutput != "file" {
+		configFatal("LOG_OUTPUT must be one of stdout, stderr or file")
+	}
+	if logOutput == "file" && getLogFile() == "" {
+		configFatal("LOG_FILE must be set when LOG_OUTPUT is file")
+	}
+	return logOutput
+}
+
+func getLogFile() string {
+	return os.Getenv("LOG_FILE")
+}
+
+func getLogFormat() string {
+	logFormat, exists := os.LookupEnv("LOG_FORMAT")
+	if !exists || logFormat == "" {
+		return "text"
+	}
+	if logFormat != "text" && logFormat != "json" {
+		configFatal("LOG_FORMAT must be either text or json")
+	}
+	return logFormat
+}
+
+func getGithubAPIVersion() string {
+	apiVersion, exists := os.LookupEnv("GITHUB_API_VERSION")
+	if !exists || apiVersion == "" {
+		return "2022-11-28"
+	}
+	if _, err := time.Parse("2006-01-02", apiVersion); err != nil {
+		configFatal("GITHUB_API_VERSION must be a date, e.g. 2022-11-28")
+	}
+	return apiVersion
+}
+
+func getGithubLogSampleRate() float64 {
+	sampleRate, exists := os.LookupEnv("GITHUB_LOG_SAMPLE_RATE")
+	if !exists || sampleRate == "" {
+		return 1
+	}
+	rate, err := strconv.ParseFloat(sampleRate, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		configFatal("GITHUB_LOG_SAMPLE_RATE must be a number between 0 and 1")
+	}
+	return rate
+}
+
+func getSlowCallThreshold() time.Duration {
+	slowCallThreshold, exists := os.LookupEnv("SLOW_CALL_THRESHOLD")
+	if !exists || slowCallThreshold == "" {
+		return 0
+	}
+	threshold, err := time.ParseDuration(slowCallThreshold)
+	if err != nil || threshold < 0 {
+		configFatal("SLOW_CALL_THRESHOLD must be a non-negative duration, e.g. 2s (0 disables the warning)")
+	}
+	return threshold
+}
+
+func getGithubMetaTTL() time.Duration {
+	metaTTL, exists := os.LookupEnv("GITHUB_META_TTL")
+	if !exists || metaTTL == "" {
+		return time.Hour
+	}
+	ttl, err := time.ParseDuration(metaTTL)
+	if err != nil || ttl <= 0 {
+		configFatal("GITHUB_META_TTL must be a positive duration, e.g. 1h")
+	}
+	return ttl
+}
+
+func getStaticCacheMaxAge() time.Duration {
+	maxAge, exists := os.LookupEnv("STATIC_CACHE_MAX_AGE")
+	if !exists || maxAge == "" {
+		return 5 * time.Minute
+	}
+	duration, err := time.ParseDuration(maxAge)
+	if err != nil || duration < 0 {
+		configFatal("STATIC_CACHE_MAX_AGE must be a non-negative duration, e.g. 5m")
+	}
+	return duration
+}
+
+func getHSTSMaxAge() time.Duration {
+	hstsMaxAge, exists := os.LookupEnv("HSTS_MAX_AGE")
+	if !exists || hstsMaxAge == "" {
+		return 180 * 24 * time.Hour
+	}
+	duration, err := time.ParseDuration(hstsMaxAge)
+	if err != nil || duration < 0 {
+		configFatal("HSTS_MAX_AGE must be a non-negative duration, e.g. 4320h (0 disables HSTS)")
+	}
+	return duration
+}
+
+func getHTTPSRedirect() bool {
+	httpsRedirect, exists := os.LookupEnv("HTTPS_REDIRECT")
+	if !exists || httpsRedirect == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(httpsRedirect)
+	if err != nil {
+		configFatal("HTTPS_REDIRECT must be a boolean")
+	}
+	return enabled
+}
+
+func getLogScopeGrants() bool {
+	logScopeGrants, exists := os.LookupEnv("LOG_SCOPE_GRANTS")
+	if !exists || logScopeGrants == "" {
+		return true
+	}
+	enabled, err := strconv.ParseBool(logScopeGrants)
+	if err != nil {
+		configFatal("LOG_SCOPE_GRANTS must be a boolean")
+	}
+	return enabled
+}
+
+func getLoginBlackoutWindows() []blackoutWindow {
+	blackoutWindows, exists := os.LookupEnv("LOGIN_BLACKOUT_WINDOWS")
+	if !exists || blackoutWindows == "" {
+		return nil
+	}
+	windows, ok := parseBlackoutWindows(blackoutWindows)
+	if !ok {
+		configFatal("LOGIN_BLACKOUT_WINDOWS must be comma-separated RFC 3339 start/end pairs, e.g. 2026-10-20T22:00:00Z/2026-10-21T02:00:00Z")
+	}
+	return windows
+}
+
+func getMaxConcurrentLogins() int {
+	maxLogins, exists := os.LookupEnv("MAX_CONCURRENT_LOGINS")
+	if !exists || maxLogins == "" {
+		return 0
+	}
+	max, err := strconv.Atoi(maxLogins)
+	if err != nil || max < 0 {
+		configFatal("MAX_CONCURRENT_LOGINS must be a non-negative integer (0 means unlimited)")
+	}
+	return max
+}
+
+func getLoginQueueTimeout() time.Duration {
+	queueTimeout, exists := os.LookupEnv("LOGIN_QUEUE_TIMEOUT")
+	if !exists || queueTimeout == "" {
+		return 10 * time.Second
+	}
+	timeout, err := time.ParseDuration(queueTimeout)
+	if err != nil || timeout < 0 {
+		configFatal("LOGIN_QUEUE_TIMEOUT must be a non-negative duration, e.g. 10s")
+	}
+	return timeout
+}
+
+func getGithubScopes() string {
+	scopes, exists := os.LookupEnv("GITHUB_SCOPES")
+	if !exists || scopes == "" {
+		return "user,read:org"
+	}
+	for _, c := range scopes {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == ':' || c == '_' || c == ',') {
+			configFatal("GITHUB_SCOPES must be a comma-separated list of GitHub scopes, e.g. user,read:org")
+			break
+		}
+	}
+	return scopes
+}
+
+func getAuthorizeURLMaxLength() int {
+	maxLength, exists := os.LookupEnv("AUTHORIZE_URL_MAX_LENGTH")
+	if !exists || maxLength == "" {
+		return 2048
+	}
+	length, err := strconv.Atoi(maxLength)
+	if err != nil || length <= 0 {
+		configFatal("AUTHORIZE_URL_MAX_LENGTH must be a positive integer")
+	}
+	return length
+}
+
+func getAllowSignup() bool {
+	allowSignup, exists := os.LookupEnv("ALLOW_SIGNUP")
+	if !exists || allowSignup == "" {
+		return true
+	}
+	enabled, err := strconv.ParseBool(allowSignup)
+	if err != nil {
+		configFatal("ALLOW_SIGNUP must be a boolean")
+	}
+	return enabled
+}
+
+func getVerifyRedirectURI() bool {
+	verifyRedirectURI, exists := os.LookupEnv("VERIFY_REDIRECT_URI")
+	if !exists || verifyRedirectURI == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(verifyRedirectURI)
+	if err != nil {
+		configFatal("VERIFY_REDIRECT_URI must be a boolean")
+	}
+	return enabled
+}
+
+func getCheckConfig() bool {
+	checkConfig, exists := os.LookupEnv("CHECK_CONFIG")
+	if !exists || checkConfig == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(checkConfig)
+	if err != nil {
+		configFatal("CHECK_CONFIG must be a boolean")
+	}
+	return enabled
+}
+
+func getOrgPageFailure() string {
+	orgPageFailure, exists := os.LookupEnv("ORG_PAGE_FAILURE")
+	if !exists || orgPageFailure == "" {
+		return "fail"
+	}
+	if orgPageFailure != "fail" && orgPageFailure != "partial" {
+		configFatal("ORG_PAGE_FAILURE must be either fail or partial")
+	}
+	return orgPageFailure
+}
+
+func getLoggedinUnauthorized() string {
+	unauthorized, exists := os.LookupEnv("LOGGEDIN_UNAUTHORIZED")
+	if !exists || unauthorized == "" {
+		return "json"
+	}
+	if unauthorized != "json" && unauthorized != "redirect" {
+		configFatal("LOGGEDIN_UNAUTHORIZED must be either json or redirect")
+	}
+	return unauthorized
+}
+
+func getLoggedinUnauthorizedStatus() int {
+	unauthorizedStatus, exists := os.LookupEnv("LOGGEDIN_UNAUTHORIZED_STATUS")
+	if !exists || unauthorizedStatus == "" {
+		return http.StatusUnauthorized
+	}
+	status, err := strconv.Atoi(unauthorizedStatus)
+	if err != nil || status < 400 || status > 599 {
+		configFatal("LOGGEDIN_UNAUTHORIZED_STATUS must be an HTTP error status between 400 and 599")
+	}
+	return status
+}
+
+func getLoggedinUnauthorizedMessage() string {
+	unauthorizedMessage, exists := os.LookupEnv("LOGGEDIN_UNAUTHORIZED_MESSAGE")
+	if !exists || unauthorizedMessage == "" {
+		return "Unauthorized"
+	}
+	return unauthorizedMessage
+}
+
+// getGithubOrganizations follows the Link header through every page of the
+// user's orgs, keeping each login once in order of first appearance. On
+// error it returns the logins gathered before the failing page alongside
+// the error, so the caller can choose to use them.
+func getGithubOrganizations(accessToken string) ([]string, error) {
 	type githubOrg struct {
 		Login string `json:"login"`
 	}
 
-	var orgs []githubOrg
-	json.Unmarshal(respbody, &orgs)
-
-	orgNames := make([]string, len(orgs))
-	for i, org := range orgs {
-		orgNames[i] = org.Login
+	var orgNames []string
+	// Page boundaries can shift while paging, repeating an org
+	seen := make(map[string]bool)
+	pageURL := "https://api.github.com/user/orgs?per_page=100"
+	for pageURL != "" {
+		respbody, header, err := getGithubAPI(pageURL, accessToken)
+		if err != nil {
+			return orgNames, err
+		}
+
+		var orgs []githubOrg
+		if err := json.Unmarshal(respbody, &orgs); err != nil {
+			return orgNames, err
+		}
+		for _, org := range orgs {
+			if seen[org.Login] {
+				continue
+			}
+			seen[org.Login] = true
+			orgNames = append(orgNames, org.Login)
+		}
+
+		pageURL = getNextPageURL(header)
 	}
 
-	return orgNames
+	if orgNames == nil {
+		orgNames = []string{}
+	}
+	return orgNames, nil
 }
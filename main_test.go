@@ -0,0 +1,694 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rewriteTransport sends every request to the test server. The original
+// host is kept in X-Original-Host so a mock can tell GitHub's hosts apart.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Original-Host", req.URL.Host)
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// mockGithub answers every call made through githubClient with handler for
+// the rest of the test.
+func mockGithub(t *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	target, _ := url.Parse(server.URL)
+
+	transport := githubClient.Transport.(*githubTransport)
+	base := transport.base
+	transport.base = rewriteTransport{target: target}
+	t.Cleanup(func() {
+		transport.base = base
+		server.Close()
+	})
+}
+
+// mockGithubLogin mocks a login that succeeds up to the organizations
+// request, which orgs answers.
+func mockGithubLogin(t *testing.T, orgs http.HandlerFunc) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", jsonHandler(`{"access_token":"gho_test","token_type":"bearer","scope":"user,read:org"}`))
+	mux.HandleFunc("/user", jsonHandler(`{"login":"octocat"}`))
+	mux.HandleFunc("/user/orgs", orgs)
+	mockGithub(t, mux.ServeHTTP)
+}
+
+func jsonHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}
+}
+
+// orgPages serves each page in turn, linking to the next one. An empty page
+// fails with a 500.
+func orgPages(pages ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page > len(pages) || pages[page-1] == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"message":"Server Error"}`)
+			return
+		}
+		if page < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<https://api.github.com/user/orgs?per_page=100&page=%d>; rel="next"`, page+1))
+		}
+		jsonHandler(pages[page-1])(w, r)
+	}
+}
+
+// setenv sets an environment variable for the rest of the test.
+func setenv(t *testing.T, key string, value string) {
+	old, existed := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if existed {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+// captureLog collects the standard logger's output for the rest of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+// captureStdout returns what f prints to os.Stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	return captureOutput(t, &os.Stdout, f)
+}
+
+// captureOutput returns what f writes to *file, os.Stdout or os.Stderr.
+func captureOutput(t *testing.T, file **os.File, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *file
+	*file = w
+	defer func() { *file = original }()
+
+	output := make(chan string)
+	go func() {
+		printed, _ := ioutil.ReadAll(r)
+		output <- string(printed)
+	}()
+	f()
+	w.Close()
+	return <-output
+}
+
+func serve(handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func serveGet(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	return serve(handler, httptest.NewRequest(http.MethodGet, target, nil))
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) errorResponse {
+	t.Helper()
+	var response errorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("error body %q is not JSON: %v", rec.Body.String(), err)
+	}
+	return response
+}
+
+type loginResponse struct {
+	GithubData string   `json:"githubData"`
+	GithubOrgs []string `json:"githubOrgs"`
+	Warnings   []string `json:"warnings"`
+}
+
+// decodeLogin reads the login result the callback passes to /loggedin.
+func decodeLogin(t *testing.T, rec *httptest.ResponseRecorder) loginResponse {
+	t.Helper()
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("callback status = %d, want %d; body %s", rec.Code, http.StatusSeeOther, rec.Body.String())
+	}
+	location := rec.Header().Get("Location")
+	if !strings.HasPrefix(location, "/loggedin?githubData=") {
+		t.Fatalf("callback redirected to %q, want /loggedin", location)
+	}
+	var response loginResponse
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(location, "/loggedin?githubData=")), &response); err != nil {
+		t.Fatalf("login result is not JSON: %v", err)
+	}
+	return response
+}
+
+func TestOrgPageFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		pages        []string
+		wantStatus   int
+		wantOrgs     []string
+		wantWarnings []string
+	}{
+		{
+			name:       "fail",
+			mode:       "fail",
+			pages:      []string{`[{"login":"a"},{"login":"b"}]`, ""},
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:         "partial",
+			mode:         "partial",
+			pages:        []string{`[{"login":"a"},{"login":"b"}]`, ""},
+			wantStatus:   http.StatusSeeOther,
+			wantOrgs:     []string{"a", "b"},
+			wantWarnings: []string{"organization list is incomplete"},
+		},
+		{
+			name:       "partial with failed first page",
+			mode:       "partial",
+			pages:      []string{""},
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setenv(t, "ORG_PAGE_FAILURE", tt.mode)
+			mockGithubLogin(t, orgPages(tt.pages...))
+			captureLog(t)
+
+			rec := serveGet(githubCallbackHandler, "/login/github/callback?code=abc")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusSeeOther {
+				if reason := decodeError(t, rec).Reason; reason != reasonOrgFetchFailed {
+					t.Errorf("reason = %q, want %q", reason, reasonOrgFetchFailed)
+				}
+				return
+			}
+
+			login := decodeLogin(t, rec)
+			if !reflect.DeepEqual(login.GithubOrgs, tt.wantOrgs) {
+				t.Errorf("orgs = %v, want %v", login.GithubOrgs, tt.wantOrgs)
+			}
+			if !reflect.DeepEqual(login.Warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", login.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestOrgsDeduplicatedAcrossPages(t *testing.T) {
+	mockGithubLogin(t, orgPages(
+		`[{"login":"alpha"},{"login":"beta"}]`,
+		`[{"login":"beta"},{"login":"gamma"}]`,
+		`[{"login":"alpha"},{"login":"delta"}]`,
+	))
+
+	orgs, err := getGithubOrganizations("gho_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alpha", "beta", "gamma", "delta"}; !reflect.DeepEqual(orgs, want) {
+		t.Errorf("orgs = %v, want %v", orgs, want)
+	}
+}
+
+func TestAuthorizeErrors(t *testing.T) {
+	setenv(t, "ALLOW_SIGNUP", "")
+
+	tests := []struct {
+		code       string
+		wantStatus int
+		wantReason string
+	}{
+		{code: "access_denied", wantStatus: http.StatusUnauthorized, wantReason: reasonConsentDenied},
+		{code: "application_suspended", wantStatus: http.StatusServiceUnavailable, wantReason: reasonMisconfigured},
+		{code: "redirect_uri_mismatch", wantStatus: http.StatusInternalServerError, wantReason: reasonMisconfigured},
+		{code: "temporarily_unavailable", wantStatus: http.StatusUnauthorized, wantReason: reasonAuthorizeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			logged := captureLog(t)
+
+			rec := serveGet(githubCallbackHandler, "/login/github/callback?error="+tt.code+"&error_description=Described+by+GitHub")
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			response := decodeError(t, rec)
+			if response.Reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", response.Reason, tt.wantReason)
+			}
+			want := unknownAuthorizeError.message
+			if known, ok := authorizeErrors[tt.code]; ok {
+				want = known.message
+			}
+			if response.Error != want {
+				t.Errorf("message = %q, want %q", response.Error, want)
+			}
+			if !strings.Contains(logged.String(), `description="Described by GitHub"`) {
+				t.Errorf("error_description was not logged: %s", logged)
+			}
+		})
+	}
+}
+
+func TestEmptyAccessToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		answer         string
+		wantStatus     int
+		wantMessage    string
+		wantLog        string
+		wantSecretHint bool
+	}{
+		{
+			name:           "incorrect client credentials",
+			answer:         `{"error":"incorrect_client_credentials","error_description":"The client_id and/or client_secret passed are incorrect."}`,
+			wantStatus:     http.StatusBadGateway,
+			wantMessage:    "GitHub returned no access token",
+			wantLog:        "[ERROR] GitHub rejected the client credentials",
+			wantSecretHint: true,
+		},
+		{
+			name:        "expired or reused code",
+			answer:      `{"error":"bad_verification_code","error_description":"The code passed is incorrect or expired."}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "The login code expired or was already used",
+			wantLog:     "[WARN] GitHub rejected the authorization code as expired or already used",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged := captureLog(t)
+			mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/login/oauth/access_token" {
+					t.Errorf("unexpected call to %s after an empty token", r.URL.Path)
+				}
+				jsonHandler(tt.answer)(w, r)
+			})
+
+			rec := serveGet(githubCallbackHandler, "/login/github/callback?code=abc")
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			response := decodeError(t, rec)
+			if response.Reason != reasonTokenExchangeFailed {
+				t.Errorf("reason = %q, want %q", response.Reason, reasonTokenExchangeFailed)
+			}
+			if response.Error != tt.wantMessage {
+				t.Errorf("message = %q, want %q", response.Error, tt.wantMessage)
+			}
+			if !strings.Contains(logged.String(), tt.wantLog) {
+				t.Errorf("log %q does not contain %q", logged, tt.wantLog)
+			}
+			if secretHint := strings.Contains(logged.String(), "check CLIENT_SECRET"); secretHint != tt.wantSecretHint {
+				t.Errorf("log points at CLIENT_SECRET = %v, want %v: %s", secretHint, tt.wantSecretHint, logged)
+			}
+		})
+	}
+}
+
+func TestTokenEndpointNotFound(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{name: "404 status", handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-GitHub-Request-Id", "ABCD:1234")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"Not Found"}`)
+		}},
+		{name: "Not Found body", handler: jsonHandler(`{"error":"Not Found"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged := captureLog(t)
+			mockGithub(t, tt.handler)
+
+			if _, err := getGithubAccessToken("abc"); !errors.Is(err, errUnknownClientID) {
+				t.Errorf("getGithubAccessToken error = %v, want errUnknownClientID", err)
+			}
+
+			rec := serveGet(githubCallbackHandler, "/login/github/callback?code=abc")
+			if rec.Code != http.StatusBadGateway {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+			}
+			if reason := decodeError(t, rec).Reason; reason != reasonMisconfigured {
+				t.Errorf("reason = %q, want %q", reason, reasonMisconfigured)
+			}
+			if !strings.Contains(logged.String(), "check CLIENT_ID") {
+				t.Errorf("404 log does not point at CLIENT_ID: %s", logged)
+			}
+		})
+	}
+}
+
+func TestRootBehavior(t *testing.T) {
+	setenv(t, "DISABLE_DEFAULT_ROOT", "")
+	setenv(t, "LOGIN_BLACKOUT_WINDOWS", "")
+
+	t.Run("page", func(t *testing.T) {
+		setenv(t, "ROOT_BEHAVIOR", "page")
+
+		rec := serveGet(rootHandler, "/")
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if !strings.Contains(rec.Body.String(), `href="/login/github/"`) {
+			t.Errorf("landing page %q has no login link", rec.Body.String())
+		}
+	})
+
+	t.Run("login_redirect", func(t *testing.T) {
+		setenv(t, "ROOT_BEHAVIOR", "login_redirect")
+
+		rec := serveGet(rootHandler, "/")
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+		}
+		loginRec := serveGet(githubLoginHandler, "/login/github/")
+		if location := rec.Header().Get("Location"); location != loginRec.Header().Get("Location") {
+			t.Errorf("redirected to %q, want the normal login redirect %q", location, loginRec.Header().Get("Location"))
+		}
+	})
+}
+
+func TestScopeGrantLogged(t *testing.T) {
+	setenv(t, "ORG_PAGE_FAILURE", "")
+	setNow(t, time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC))
+	mockGithubLogin(t, orgPages(`[]`))
+
+	setenv(t, "LOG_SCOPE_GRANTS", "true")
+	logged := captureLog(t)
+	decodeLogin(t, serveGet(githubCallbackHandler, "/login/github/callback?code=abc"))
+
+	want := `[INFO] scope grant: user=octocat scopes="user,read:org" time=2026-10-14T12:00:00Z`
+	if !strings.Contains(logged.String(), want) {
+		t.Errorf("log %q is missing the scope grant %q", logged, want)
+	}
+	if strings.Contains(logged.String(), "gho_test") {
+		t.Errorf("log leaks the access token: %s", logged)
+	}
+
+	setenv(t, "LOG_SCOPE_GRANTS", "false")
+	logged = captureLog(t)
+	decodeLogin(t, serveGet(githubCallbackHandler, "/login/github/callback?code=abc"))
+	if strings.Contains(logged.String(), "scope grant") {
+		t.Errorf("scope grant logged with LOG_SCOPE_GRANTS=false: %s", logged)
+	}
+}
+
+func TestAllowSignup(t *testing.T) {
+	setenv(t, "GITHUB_SCOPES", "")
+	setenv(t, "AUTHORIZE_URL_MAX_LENGTH", "")
+
+	setenv(t, "ALLOW_SIGNUP", "false")
+	authorizeURL, err := getGithubAuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, _ := url.Parse(authorizeURL); parsed.Query().Get("allow_signup") != "false" {
+		t.Errorf("authorize URL %q lacks allow_signup=false", authorizeURL)
+	}
+
+	captureLog(t)
+	rec := serveGet(githubCallbackHandler, "/login/github/callback?error=access_denied")
+	if message := decodeError(t, rec).Error; !strings.Contains(message, "existing GitHub accounts") {
+		t.Errorf("denied login message = %q, want the existing accounts explanation", message)
+	}
+
+	setenv(t, "ALLOW_SIGNUP", "true")
+	authorizeURL, _ = getGithubAuthorizeURL()
+	if strings.Contains(authorizeURL, "allow_signup") {
+		t.Errorf("authorize URL %q has allow_signup when signups are allowed", authorizeURL)
+	}
+}
+
+func TestCallbackQueryAndFragment(t *testing.T) {
+	setenv(t, "ORG_PAGE_FAILURE", "")
+	captureLog(t)
+	mockGithubLogin(t, orgPages(`[{"login":"a"}]`))
+
+	login := decodeLogin(t, serveGet(githubCallbackHandler, "/login/github/callback?code=abc&state=xyz"))
+	if login.GithubData != `{"login":"octocat"}` {
+		t.Errorf("githubData = %q, want the mocked user", login.GithubData)
+	}
+
+	rec := serveGet(githubCallbackHandler, "/login/github/callback")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("callback without a code answered %d %s, want the HTML bootstrap", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "window.location.hash") {
+		t.Errorf("bootstrap page does not read the fragment: %s", rec.Body.String())
+	}
+}
+
+func TestLoggedinUnauthorized(t *testing.T) {
+	browser := httptest.NewRequest(http.MethodGet, "/loggedin", nil)
+	browser.Header.Set("Accept", "text/html,application/xhtml+xml")
+	api := httptest.NewRequest(http.MethodGet, "/loggedin", nil)
+	api.Header.Set("Accept", "application/json")
+
+	t.Run("json", func(t *testing.T) {
+		setenv(t, "LOGGEDIN_UNAUTHORIZED", "json")
+		setenv(t, "LOGGEDIN_UNAUTHORIZED_STATUS", "403")
+		setenv(t, "LOGGEDIN_UNAUTHORIZED_MESSAGE", "Log in first")
+
+		rec := serve(func(w http.ResponseWriter, r *http.Request) { loggedinHandler(w, r, "") }, browser)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+		}
+		if message := decodeError(t, rec).Error; message != "Log in first" {
+			t.Errorf("message = %q, want the configured message", message)
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		setenv(t, "LOGGEDIN_UNAUTHORIZED", "redirect")
+		setenv(t, "LOGGEDIN_UNAUTHORIZED_STATUS", "")
+		setenv(t, "LOGGEDIN_UNAUTHORIZED_MESSAGE", "")
+
+		rec := serve(func(w http.ResponseWriter, r *http.Request) { loggedinHandler(w, r, "") }, browser)
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/login/github/" {
+			t.Errorf("browser got %d to %q, want 302 to /login/github/", rec.Code, rec.Header().Get("Location"))
+		}
+
+		rec = serve(func(w http.ResponseWriter, r *http.Request) { loggedinHandler(w, r, "") }, api)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("API client status = %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+		if message := decodeError(t, rec).Error; message != "Unauthorized" {
+			t.Errorf("API client message = %q, want Unauthorized", message)
+		}
+	})
+}
+
+func TestAuthorizeURLTooLong(t *testing.T) {
+	setenv(t, "LOGIN_BLACKOUT_WINDOWS", "")
+	setenv(t, "AUTHORIZE_URL_MAX_LENGTH", "")
+	setenv(t, "GITHUB_SCOPES", strings.Repeat("read:org,", 250)+"user")
+	logged := captureLog(t)
+
+	if _, err := getGithubAuthorizeURL(); err == nil || !strings.Contains(err.Error(), "AUTHORIZE_URL_MAX_LENGTH") {
+		t.Errorf("error = %v, want the URL length error", err)
+	}
+
+	rec := serveGet(githubLoginHandler, "/login/github/")
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if reason := decodeError(t, rec).Reason; reason != reasonMisconfigured {
+		t.Errorf("reason = %q, want %q", reason, reasonMisconfigured)
+	}
+	if rec.Header().Get("Location") != "" {
+		t.Error("oversized authorize URL was still redirected to")
+	}
+	if !strings.Contains(logged.String(), "[ERROR] Cannot start GitHub login") {
+		t.Errorf("oversized URL was not logged as an error: %s", logged)
+	}
+}
+
+func TestLoginProvider(t *testing.T) {
+	setenv(t, "LOGIN_BLACKOUT_WINDOWS", "")
+
+	rec := serveGet(loginHandler, "/login?provider=github")
+	if location := rec.Header().Get("Location"); rec.Code != http.StatusMovedPermanently || !strings.HasPrefix(location, "https://github.com/login/oauth/authorize?") {
+		t.Errorf("provider=github answered %d to %q, want the GitHub login", rec.Code, location)
+	}
+
+	for _, target := range []string{"/login?provider=gitlab", "/login"} {
+		rec := serveGet(loginHandler, target)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s status = %d, want %d", target, rec.Code, http.StatusBadRequest)
+		}
+		if reason := decodeError(t, rec).Reason; reason != reasonUnknownProvider {
+			t.Errorf("%s reason = %q, want %q", target, reason, reasonUnknownProvider)
+		}
+	}
+}
+
+func TestLoginFailureReasons(t *testing.T) {
+	failWith := func(status int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(status) }
+	}
+
+	tests := []struct {
+		reason  string
+		setup   func(t *testing.T)
+		handler http.HandlerFunc
+		target  string
+	}{
+		{
+			reason:  reasonConsentDenied,
+			handler: githubCallbackHandler,
+			target:  "/login/github/callback?error=access_denied",
+		},
+		{
+			reason:  reasonAuthorizeFailed,
+			handler: githubCallbackHandler,
+			target:  "/login/github/callback?error=server_error",
+		},
+		{
+			reason:  reasonUnknownProvider,
+			handler: loginHandler,
+			target:  "/login?provider=bitbucket",
+		},
+		{
+			reason: reasonLoginUnavailable,
+			setup: func(t *testing.T) {
+				setenv(t, "LOGIN_BLACKOUT_WINDOWS", "2026-10-20T22:00:00Z/2026-10-21T02:00:00Z")
+				setNow(t, time.Date(2026, 10, 20, 23, 0, 0, 0, time.UTC))
+			},
+			handler: githubLoginHandler,
+			target:  "/login/github/",
+		},
+		{
+			reason: reasonTooManyLogins,
+			setup: func(t *testing.T) {
+				useLoginSlots(t, 1)
+				setenv(t, "LOGIN_QUEUE_TIMEOUT", "0")
+				loginSlots <- struct{}{}
+			},
+			handler: githubCallbackHandler,
+			target:  "/login/github/callback?code=abc",
+		},
+		{
+			reason: reasonMisconfigured,
+			setup: func(t *testing.T) {
+				setenv(t, "AUTHORIZE_URL_MAX_LENGTH", "64")
+			},
+			handler: githubLoginHandler,
+			target:  "/login/github/",
+		},
+		{
+			reason: reasonTokenExchangeFailed,
+			setup: func(t *testing.T) {
+				mockGithub(t, jsonHandler(`{"error":"bad_verification_code"}`))
+			},
+			handler: githubCallbackHandler,
+			target:  "/login/github/callback?code=abc",
+		},
+		{
+			reason: reasonProfileFetchFailed,
+			setup: func(t *testing.T) {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/login/oauth/access_token", jsonHandler(`{"access_token":"gho_test"}`))
+				mux.HandleFunc("/user", failWith(http.StatusUnauthorized))
+				mockGithub(t, mux.ServeHTTP)
+			},
+			handler: githubCallbackHandler,
+			target:  "/login/github/callback?code=abc",
+		},
+		{
+			reason: reasonOrgFetchFailed,
+			setup: func(t *testing.T) {
+				mockGithubLogin(t, failWith(http.StatusForbidden))
+			},
+			handler: githubCallbackHandler,
+			target:  "/login/github/callback?code=abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			setenv(t, "LOGIN_BLACKOUT_WINDOWS", "")
+			setenv(t, "AUTHORIZE_URL_MAX_LENGTH", "")
+			captureLog(t)
+			if tt.setup != nil {
+				tt.setup(t)
+			}
+
+			response := decodeError(t, serveGet(tt.handler, tt.target))
+			if response.Reason != tt.reason {
+				t.Errorf("reason = %q, want %q", response.Reason, tt.reason)
+			}
+			if response.Error == "" {
+				t.Error("failure has no human-readable message")
+			}
+		})
+	}
+}
+
+func TestDisableDefaultRoot(t *testing.T) {
+	setenv(t, "ERROR_FORMAT", "")
+
+	setenv(t, "DISABLE_DEFAULT_ROOT", "true")
+	rec := serveGet(rootHandler, "/")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if strings.Contains(rec.Body.String(), "LOGIN") {
+		t.Errorf("disabled root still serves the login link: %s", rec.Body.String())
+	}
+
+	setenv(t, "DISABLE_DEFAULT_ROOT", "false")
+	if rec := serveGet(rootHandler, "/"); rec.Code != http.StatusOK {
+		t.Errorf("enabled root status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
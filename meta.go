@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// githubMeta is the subset of GitHub's /meta response useful for verifying
+// SSH host keys and GitHub-originated traffic.
+type githubMeta struct {
+	SSHKeyFingerprints map[string]string `json:"ssh_key_fingerprints"`
+	SSHKeys            []string          `json:"ssh_keys"`
+	Hooks              []string          `json:"hooks"`
+	Web                []string          `json:"web"`
+	API                []string          `json:"api"`
+	Git                []string          `json:"git"`
+}
+
+type githubMetaCache struct {
+	mu        sync.RWMutex
+	metaJSON  []byte
+	fetchedAt time.Time
+	inflight  *metaRefresh
+}
+
+// metaRefresh is a GitHub meta fetch in progress, shared by every caller
+// that asks for a refresh before it finishes.
+type metaRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+var metaCache githubMetaCache
+
+func (c *githubMetaCache) get() ([]byte, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.metaJSON, c.fetchedAt
+}
+
+// refresh fetches GitHub's meta into the cache. Concurrent callers wait for
+// the fetch already in progress, since the endpoint is unauthenticated and
+// every call spends the 60 per hour anonymous rate limit.
+func (c *githubMetaCache) refresh() error {
+	c.mu.Lock()
+	if call := c.inflight; call != nil {
+		c.mu.Unlock()
+		<-call.done
+		return call.err
+	}
+	call := &metaRefresh{done: make(chan struct{})}
+	c.inflight = call
+	c.mu.Unlock()
+
+	meta, err := getGithubMeta()
+
+	c.mu.Lock()
+	if err == nil {
+		c.metaJSON, _ = json.MarshalIndent(meta, "", "\t")
+		c.fetchedAt = time.Now()
+	}
+	c.inflight = nil
+	c.mu.Unlock()
+
+	call.err = err
+	close(call.done)
+	return err
+}
+
+// metaRetryDelay is how soon a failed background refresh is retried. It
+// doubles with each consecutive failure, up to ttl.
+const metaRetryDelay = 30 * time.Second
+
+func nextMetaRefresh(ttl time.Duration, failures int) time.Duration {
+	if failures == 0 {
+		return ttl
+	}
+	delay := metaRetryDelay
+	for i := 1; i < failures && delay < ttl; i++ {
+		delay *= 2
+	}
+	if delay > ttl {
+		return ttl
+	}
+	return delay
+}
+
+// startGithubMetaRefresher fills the cache once and then refreshes it every
+// ttl so requests to /meta never wait on GitHub. Failed refreshes are
+// retried sooner, see nextMetaRefresh.
+func startGithubMetaRefresher(ttl time.Duration) {
+	go func() {
+		failures := 0
+		for {
+			if err := metaCache.refresh(); err != nil {
+				log.Println("[WARN] GitHub meta refresh failed:", err)
+				failures++
+			} else {
+				failures = 0
+			}
+			time.Sleep(nextMetaRefresh(ttl, failures))
+		}
+	}()
+}
+
+func metaHandler(w http.ResponseWriter, r *http.Request) {
+	metaJSON, fetchedAt := metaCache.get()
+	if metaJSON == nil {
+		// Background refresh has not succeeded yet, fetch inline
+		if err := metaCache.refresh(); err != nil {
+			log.Println("[ERROR] GitHub meta fetch failed:", err)
+			writeJSONError(w, http.StatusBadGateway, "GitHub meta unavailable", "")
+			return
+		}
+		metaJSON, fetchedAt = metaCache.get()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Last-Modified", fetchedAt.UTC().Format(http.TimeFormat))
+	fmt.Fprint(w, string(metaJSON))
+}
+
+func getGithubMeta() (githubMeta, error) {
+	var meta githubMeta
+
+	req, reqerr := http.NewRequest("GET", "https://api.github.com/meta", nil)
+	if reqerr != nil {
+		return meta, reqerr
+	}
+	req.Header.Set("Accept", "application/json")
+
+	respbody, _, resperr := doGithubRequest(req)
+	if resperr != nil {
+		return meta, resperr
+	}
+
+	if err := json.Unmarshal(respbody, &meta); err != nil {
+		return meta, err
+	}
+	return meta, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func resetMetaCache(t *testing.T) {
+	metaCache = githubMetaCache{}
+	t.Cleanup(func() { metaCache = githubMetaCache{} })
+}
+
+func TestMetaHandler(t *testing.T) {
+	resetMetaCache(t)
+
+	var hits int32
+	release := make(chan struct{})
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if host := r.Header.Get("X-Original-Host"); host != "api.github.com" || r.URL.Path != "/meta" {
+			t.Errorf("meta fetched from %s%s, want api.github.com/meta", host, r.URL.Path)
+		}
+		<-release
+		jsonHandler(`{"ssh_key_fingerprints":{"SHA256_ED25519":"+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU"},"hooks":["192.30.252.0/22"]}`)(w, r)
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	bodies := make([][]byte, len(codes))
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := serveGet(metaHandler, "/meta")
+			codes[i], bodies[i] = rec.Code, rec.Body.Bytes()
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("GitHub meta was fetched %d times, want 1", hits)
+	}
+	for i := range codes {
+		if codes[i] != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d", i, codes[i], http.StatusOK)
+		}
+		var meta githubMeta
+		if err := json.Unmarshal(bodies[i], &meta); err != nil {
+			t.Fatalf("request %d body is not JSON: %v", i, err)
+		}
+		if meta.SSHKeyFingerprints["SHA256_ED25519"] == "" || len(meta.Hooks) != 1 {
+			t.Errorf("request %d meta = %+v, want the mocked fingerprints and hooks", i, meta)
+		}
+	}
+
+	rec := serveGet(metaHandler, "/meta")
+	if rec.Header().Get("Last-Modified") == "" {
+		t.Error("Last-Modified is not set")
+	}
+	if hits := atomic.LoadInt32(&hits); hits != 1 {
+		t.Errorf("cached meta was fetched again, %d fetches", hits)
+	}
+}
+
+func TestMetaHandlerUnavailable(t *testing.T) {
+	resetMetaCache(t)
+	captureLog(t)
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	rec := serveGet(metaHandler, "/meta")
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+}
+
+func TestNextMetaRefresh(t *testing.T) {
+	ttl := 5 * time.Minute
+	for failures, want := range []time.Duration{ttl, 30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, ttl, ttl} {
+		if got := nextMetaRefresh(ttl, failures); got != want {
+			t.Errorf("nextMetaRefresh(%s, %d) = %s, want %s", ttl, failures, got, want)
+		}
+	}
+}
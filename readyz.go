@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// readinessCheck is one dependency reported by /readyz. Only required
+// dependencies can make the service unready.
+type readinessCheck struct {
+	name     string
+	required bool
+	check    func() error
+}
+
+var readinessChecks = []readinessCheck{
+	{name: "github", required: true, check: checkGithubReachable},
+	{name: "github_meta", required: false, check: checkGithubMetaCached},
+}
+
+// checkGithubReachable calls GitHub through githubClient, so it takes the
+// same proxy route as real calls. The rate_limit endpoint is used because
+// it does not count against the rate limit; any answer short of a server
+// error means GitHub is reachable.
+func checkGithubReachable() error {
+	client := *githubClient
+	client.Timeout = 3 * time.Second
+
+	resp, err := client.Get("https://api.github.com/rate_limit")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("GitHub answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func checkGithubMetaCached() error {
+	if metaJSON, _ := metaCache.get(); metaJSON == nil {
+		return errors.New("GitHub meta not fetched yet")
+	}
+	return nil
+}
+
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	statuses := make(map[string]string, len(readinessChecks))
+	failing := []string{}
+	for _, rc := range readinessChecks {
+		if err := rc.check(); err != nil {
+			statuses[rc.name] = "error: " + err.Error()
+			if rc.required {
+				failing = append(failing, rc.name)
+			}
+			continue
+		}
+		statuses[rc.name] = "ok"
+	}
+
+	response := struct {
+		Status       string            `json:"status"`
+		Dependencies map[string]string `json:"dependencies"`
+		Failing      []string          `json:"failing,omitempty"`
+	}{
+		Status:       "ok",
+		Dependencies: statuses,
+		Failing:      failing,
+	}
+
+	status := http.StatusOK
+	if len(failing) > 0 {
+		response.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+
+	responseJSON, _ := json.MarshalIndent(response, "", "\t")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprint(w, string(responseJSON))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type readyzResponse struct {
+	Status       string            `json:"status"`
+	Dependencies map[string]string `json:"dependencies"`
+	Failing      []string          `json:"failing"`
+}
+
+func useReadinessChecks(t *testing.T, checks ...readinessCheck) {
+	saved := readinessChecks
+	readinessChecks = checks
+	t.Cleanup(func() { readinessChecks = saved })
+}
+
+func serveReadyz(t *testing.T) (int, readyzResponse) {
+	t.Helper()
+	rec := serveGet(readyzHandler, "/readyz")
+	var response readyzResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("body %q is not JSON: %v", rec.Body.String(), err)
+	}
+	return rec.Code, response
+}
+
+func TestReadyzFailingDependency(t *testing.T) {
+	healthy := func() error { return nil }
+	down := func() error { return errors.New("connection refused") }
+	useReadinessChecks(t,
+		readinessCheck{name: "github", required: true, check: healthy},
+		readinessCheck{name: "store", required: true, check: down},
+		readinessCheck{name: "github_meta", required: false, check: down},
+	)
+
+	status, response := serveReadyz(t)
+	if status != http.StatusServiceUnavailable || response.Status != "unavailable" {
+		t.Errorf("got %d %q, want 503 unavailable", status, response.Status)
+	}
+	if !reflect.DeepEqual(response.Failing, []string{"store"}) {
+		t.Errorf("failing = %v, want only the required store", response.Failing)
+	}
+	want := map[string]string{"github": "ok", "store": "error: connection refused", "github_meta": "error: connection refused"}
+	if !reflect.DeepEqual(response.Dependencies, want) {
+		t.Errorf("dependencies = %v, want %v", response.Dependencies, want)
+	}
+}
+
+func TestReadyzOptionalDependencyDown(t *testing.T) {
+	useReadinessChecks(t,
+		readinessCheck{name: "github", required: true, check: func() error { return nil }},
+		readinessCheck{name: "github_meta", required: false, check: func() error { return errors.New("not fetched") }},
+	)
+
+	status, response := serveReadyz(t)
+	if status != http.StatusOK || response.Status != "ok" || len(response.Failing) != 0 {
+		t.Errorf("got %d %+v, want 200 ok with nothing failing", status, response)
+	}
+}
+
+func TestCheckGithubReachable(t *testing.T) {
+	captureLog(t)
+	for _, tt := range []struct {
+		status  int
+		wantErr bool
+	}{
+		{status: http.StatusOK},
+		{status: http.StatusForbidden},
+		{status: http.StatusServiceUnavailable, wantErr: true},
+	} {
+		mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+			if host := r.Header.Get("X-Original-Host"); host != "api.github.com" || r.URL.Path != "/rate_limit" {
+				t.Errorf("readiness check called %s%s, want api.github.com/rate_limit", host, r.URL.Path)
+			}
+			w.WriteHeader(tt.status)
+		})
+
+		if err := checkGithubReachable(); (err != nil) != tt.wantErr {
+			t.Errorf("GitHub answering %d: check error = %v, want error %v", tt.status, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// route describes one endpoint and the HTTP methods it accepts. Only
+// cacheable routes may be cached by browsers and CDNs; every other route
+// is sent with Cache-Control: no-store.
+type route struct {
+	path      string
+	methods   []string
+	cacheable bool
+	handler   http.HandlerFunc
+}
+
+// registeredPaths records every path passed to handle, so requests that
+// differ from a route only by a trailing slash can be sent to it.
+var registeredPaths = map[string]bool{}
+
+func handle(mux *http.ServeMux, rt route) {
+	registeredPaths[rt.path] = true
+	mux.HandleFunc(rt.path, exactPathHandler(rt.path, methodHandler(rt.methods, cacheHandler(rt.cacheable, rt.handler))))
+}
+
+// exactPathHandler stops ServeMux's subtree matching for paths ending in a
+// slash, so / and /login/github/ no longer answer every path beneath them.
+func exactPathHandler(path string, next http.HandlerFunc) http.HandlerFunc {
+	if !strings.HasSuffix(path, "/") {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			notFoundHandler(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// notFoundHandler answers unknown paths in the app's error format, or with a
+// small page for browsers. The page only links home when there is one.
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	if acceptsHTML(r) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		if getDisableDefaultRoot() {
+			fmt.Fprint(w, `<p>Page not found.</p>`)
+			return
+		}
+		fmt.Fprint(w, `<p>Page not found. <a href="/">HOME</a></p>`)
+		return
+	}
+	writeJSONError(w, http.StatusNotFound, "Not found", "")
+}
+
+func cacheHandler(cacheable bool, next http.HandlerFunc) http.HandlerFunc {
+	cacheControl := "no-store"
+	if cacheable {
+		cacheControl = fmt.Sprintf("public, max-age=%d", int(getStaticCacheMaxAge().Seconds()))
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", cacheControl)
+		next(w, r)
+	}
+}
+
+// methodHandler rejects methods not in allowed with a 405 and an Allow
+// header. HEAD is accepted wherever GET is.
+func methodHandler(allowed []string, next http.HandlerFunc) http.HandlerFunc {
+	allowedSet := make(map[string]bool, len(allowed)+1)
+	for _, method := range allowed {
+		allowedSet[method] = true
+	}
+	if allowedSet[http.MethodGet] && !allowedSet[http.MethodHead] {
+		allowedSet[http.MethodHead] = true
+		allowed = append(allowed[:len(allowed):len(allowed)], http.MethodHead)
+	}
+	allowHeader := strings.Join(allowed, ", ")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowedSet[r.Method] {
+			w.Header().Set("Allow", allowHeader)
+			writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// trailingSlashHandler redirects /foo/ to /foo (and /foo to /foo/) when only
+// the other form is registered. 308 keeps the method and body intact.
+func trailingSlashHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if path != "/" && !registeredPaths[path] {
+			alternate := path + "/"
+			if strings.HasSuffix(path, "/") {
+				alternate = strings.TrimSuffix(path, "/")
+			}
+			if registeredPaths[alternate] {
+				target := *r.URL
+				target.Path = alternate
+				http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// appHandler is the server's handler chain around appRoutes, on a fresh mux.
+func appHandler(t *testing.T) http.Handler {
+	saved := registeredPaths
+	registeredPaths = map[string]bool{}
+	t.Cleanup(func() { registeredPaths = saved })
+
+	mux := http.NewServeMux()
+	for _, rt := range appRoutes() {
+		handle(mux, rt)
+	}
+	return httpsHandler(trailingSlashHandler(mux))
+}
+
+func serveApp(t *testing.T, method string, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	appHandler(t).ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestTrailingSlashRedirects(t *testing.T) {
+	tests := []struct {
+		target       string
+		wantLocation string
+	}{
+		{target: "/login/github", wantLocation: "/login/github/"},
+		{target: "/login/github?next=1", wantLocation: "/login/github/?next=1"},
+		{target: "/loggedin/", wantLocation: "/loggedin"},
+		{target: "/readyz/", wantLocation: "/readyz"},
+	}
+
+	for _, tt := range tests {
+		rec := serveApp(t, http.MethodGet, tt.target)
+		if rec.Code != http.StatusPermanentRedirect {
+			t.Errorf("%s status = %d, want %d", tt.target, rec.Code, http.StatusPermanentRedirect)
+		}
+		if location := rec.Header().Get("Location"); location != tt.wantLocation {
+			t.Errorf("%s redirected to %q, want %q", tt.target, location, tt.wantLocation)
+		}
+	}
+}
+
+func TestTrailingSlashRegisteredForms(t *testing.T) {
+	rec := serveApp(t, http.MethodGet, "/login/github/")
+	if location := rec.Header().Get("Location"); !strings.HasPrefix(location, "https://github.com/login/oauth/authorize?") {
+		t.Errorf("/login/github/ redirected to %q, want the GitHub authorize URL", location)
+	}
+
+	rec = serveApp(t, http.MethodGet, "/loggedin")
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("/loggedin status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestDisallowedMethods(t *testing.T) {
+	for _, target := range []string{"/login/github/", "/login/github/callback"} {
+		rec := serveApp(t, http.MethodPost, target)
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("POST %s status = %d, want %d", target, rec.Code, http.StatusMethodNotAllowed)
+		}
+		if allow := rec.Header().Get("Allow"); allow != "GET, HEAD" {
+			t.Errorf("POST %s Allow = %q, want \"GET, HEAD\"", target, allow)
+		}
+		if response := decodeError(t, rec); response.Error != "Method not allowed" {
+			t.Errorf("POST %s error = %q, want \"Method not allowed\"", target, response.Error)
+		}
+	}
+
+	rec := serveApp(t, http.MethodHead, "/login/github/")
+	if rec.Code == http.StatusMethodNotAllowed {
+		t.Error("HEAD /login/github/ was refused")
+	}
+}
+
+func TestMethodHandlerPostOnly(t *testing.T) {
+	handler := methodHandler([]string{http.MethodPost}, func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := serveGet(handler, "/")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "POST" {
+		t.Errorf("Allow = %q, want POST", allow)
+	}
+}
+
+func TestUnknownRoutes(t *testing.T) {
+	setenv(t, "ERROR_FORMAT", "")
+	setenv(t, "DISABLE_DEFAULT_ROOT", "")
+
+	for _, target := range []string{"/nope", "/login/github/extra", "/meta/extra", "/static/app.js"} {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			rec := serveApp(t, method, target)
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("%s %s status = %d, want %d", method, target, rec.Code, http.StatusNotFound)
+				continue
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("%s %s Content-Type = %q, want application/json", method, target, contentType)
+			}
+			if body := rec.Body.String(); body != `{"error":"Not found"}` {
+				t.Errorf("%s %s body = %s, want the JSON 404", method, target, body)
+			}
+		}
+	}
+}
+
+func TestUnknownRouteHTML(t *testing.T) {
+	for _, tt := range []struct {
+		disableDefaultRoot string
+		wantHomeLink       bool
+	}{
+		{disableDefaultRoot: "", wantHomeLink: true},
+		{disableDefaultRoot: "true", wantHomeLink: false},
+	} {
+		setenv(t, "DISABLE_DEFAULT_ROOT", tt.disableDefaultRoot)
+
+		req := httptest.NewRequest(http.MethodGet, "/nope", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		rec := httptest.NewRecorder()
+		appHandler(t).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+			t.Errorf("DISABLE_DEFAULT_ROOT=%q: got %d %s, want an HTML 404", tt.disableDefaultRoot, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if hasHomeLink := strings.Contains(rec.Body.String(), `href="/"`); hasHomeLink != tt.wantHomeLink {
+			t.Errorf("DISABLE_DEFAULT_ROOT=%q: home link shown = %v, want %v", tt.disableDefaultRoot, hasHomeLink, tt.wantHomeLink)
+		}
+	}
+}
+
+func TestRealRoutesNotShadowed(t *testing.T) {
+	setenv(t, "DISABLE_DEFAULT_ROOT", "")
+	setenv(t, "ROOT_BEHAVIOR", "")
+	setenv(t, "LOGIN_BLACKOUT_WINDOWS", "")
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{target: "/", wantStatus: http.StatusOK},
+		{target: "/login?provider=github", wantStatus: http.StatusMovedPermanently},
+		{target: "/login/github/", wantStatus: http.StatusMovedPermanently},
+		{target: "/login/github/callback", wantStatus: http.StatusOK},
+		{target: "/loggedin", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if rec := serveApp(t, http.MethodGet, tt.target); rec.Code != tt.wantStatus {
+			t.Errorf("%s status = %d, want %d", tt.target, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestCacheHeaders(t *testing.T) {
+	resetMetaCache(t)
+	metaCache.metaJSON = []byte(`{}`)
+
+	setenv(t, "STATIC_CACHE_MAX_AGE", "")
+	if cacheControl := serveApp(t, http.MethodGet, "/meta").Header().Get("Cache-Control"); cacheControl != "public, max-age=300" {
+		t.Errorf("/meta Cache-Control = %q, want public, max-age=300", cacheControl)
+	}
+
+	setenv(t, "STATIC_CACHE_MAX_AGE", "1h")
+	if cacheControl := serveApp(t, http.MethodGet, "/meta").Header().Get("Cache-Control"); cacheControl != "public, max-age=3600" {
+		t.Errorf("/meta Cache-Control = %q, want public, max-age=3600", cacheControl)
+	}
+
+	for _, target := range []string{"/loggedin?githubData={}", "/login/github/callback"} {
+		if cacheControl := serveApp(t, http.MethodGet, target).Header().Get("Cache-Control"); cacheControl != "no-store" {
+			t.Errorf("%s Cache-Control = %q, want no-store", target, cacheControl)
+		}
+	}
+
+	// Errors must not be cached even on a cacheable route
+	metaCache.metaJSON = nil
+	captureLog(t)
+	mockGithub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	if cacheControl := serveApp(t, http.MethodGet, "/meta").Header().Get("Cache-Control"); cacheControl != "no-store" {
+		t.Errorf("/meta error Cache-Control = %q, want no-store", cacheControl)
+	}
+}